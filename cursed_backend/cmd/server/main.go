@@ -4,8 +4,10 @@ import (
 	"context"
 	"cursed_backend/internal/config"
 	"cursed_backend/internal/db"
+	"cursed_backend/internal/handlers"
 	"cursed_backend/internal/logger"
 	"cursed_backend/internal/metrics"
+	"cursed_backend/internal/middleware"
 	"cursed_backend/internal/router"
 	"errors"
 	"log"
@@ -55,6 +57,15 @@ func main() {
 	r := router.SetupRouter(cfg)
 	logger.Log.Info("✅ Router setup complete")
 
+	// Background jobs
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	handlers.StartReservationSweeper(jobsCtx, cfg.ReservationSweepInterval)
+	middleware.StartIdempotencyPurge(jobsCtx, time.Hour, cfg.IdempotencyKeyTTL)
+	handlers.StartLowStockMonitor(jobsCtx, cfg.LowStockRefreshInterval)
+	handlers.StartSubscriptionScheduler(jobsCtx, cfg.SubscriptionRunInterval)
+	handlers.StartPriceChangeScheduler(jobsCtx, cfg.PriceChangeInterval)
+
 	// Server setup
 	srv := &http.Server{
 		Addr:         ":" + cfg.Port,
@@ -84,6 +95,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	logger.Log.Info("Shutting down server...")
+	stopJobs()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
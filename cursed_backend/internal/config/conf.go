@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 type Config struct {
 	Port        string `env:"PORT" envDefault:"8080"`
 	Env         string `env:"ENV" envDefault:"dev"`
@@ -13,4 +15,28 @@ type Config struct {
 	CSRFKey     string `env:"CSRF_KEY"`
 	LogLevel    string `env:"LOG_LEVEL" envDefault:"info"`
 	CORSOrigins string `env:"CORS_ORIGINS" envDefault:"http://localhost:3000,http://localhost:5173"`
+
+	Currency string `env:"CURRENCY" envDefault:"USD"`
+
+	ReturnWindowDays        int      `env:"RETURN_WINDOW_DAYS" envDefault:"14"`
+	ReturnConditions        []string `env:"RETURN_CONDITIONS" envDefault:"unopened,opened" envSeparator:","`
+	ReturnRestockConditions []string `env:"RETURN_RESTOCK_CONDITIONS" envDefault:"unopened" envSeparator:","`
+
+	// When set, store pets can only be taken home through an approved adoption application
+	AdoptionRequired bool `env:"ADOPTION_REQUIRED" envDefault:"false"`
+
+	PetHoldDuration          time.Duration `env:"PET_HOLD_DURATION" envDefault:"48h"`
+	PetMaxHoldsPerUser       int           `env:"PET_MAX_HOLDS_PER_USER" envDefault:"2"`
+	ReservationSweepInterval time.Duration `env:"RESERVATION_SWEEP_INTERVAL" envDefault:"1m"`
+
+	// Points earned per whole currency unit charged, and what one point is worth in cents when redeemed
+	LoyaltyPointsPerUnit int64 `env:"LOYALTY_POINTS_PER_UNIT" envDefault:"1"`
+	LoyaltyPointValue    int64 `env:"LOYALTY_POINT_VALUE" envDefault:"1"`
+
+	IdempotencyKeyTTL time.Duration `env:"IDEMPOTENCY_KEY_TTL" envDefault:"24h"`
+
+	LowStockRefreshInterval time.Duration `env:"LOW_STOCK_REFRESH_INTERVAL" envDefault:"1m"`
+
+	SubscriptionRunInterval time.Duration `env:"SUBSCRIPTION_RUN_INTERVAL" envDefault:"5m"`
+	PriceChangeInterval     time.Duration `env:"PRICE_CHANGE_INTERVAL" envDefault:"1m"`
 }
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"cursed_backend/internal/logger"
+	"cursed_backend/internal/models"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// currencyColumns are the currency columns that default to the store
+// currency. backfill marks the tables whose rows are moved to a new store
+// currency; orders keep the currency they were paid in.
+var currencyColumns = []struct {
+	model    interface{}
+	table    string
+	where    string
+	backfill bool
+}{
+	{&models.Pet{}, "pets", "owner_id = 0", true},
+	{&models.Product{}, "products", "owner_id = 0", true},
+	{&models.Bundle{}, "bundles", "", true},
+	{&models.GiftCard{}, "gift_cards", "", true},
+	{&models.Order{}, "orders", "", false},
+}
+
+// useStoreCurrency makes the configured currency the default of every
+// currency column. The model tags say USD, the default of CURRENCY; the
+// parsed schemas are changed instead, so rows GORM creates and the column
+// defaults AutoMigrate maintains both follow the configuration. It must run
+// before AutoMigrate.
+//
+// When the currency differs from the column default left by the previous
+// start, store items and gift cards still in the old currency are moved to
+// the new one so they stay purchasable and redeemable. Amounts are not
+// converted.
+func useStoreCurrency(db *gorm.DB, currency string) error {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if len(currency) != 3 || strings.Trim(currency, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return fmt.Errorf("invalid store currency %q", currency)
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, cc := range currencyColumns {
+			if !cc.backfill {
+				continue
+			}
+			var columnDefault string
+			if err := tx.Raw("SELECT COALESCE(column_default, '') FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = ? AND column_name = 'currency'",
+				cc.table).Scan(&columnDefault).Error; err != nil {
+				return err
+			}
+			// Postgres reports the default as 'USD'::bpchar
+			previous, _, _ := strings.Cut(strings.TrimPrefix(columnDefault, "'"), "'")
+			if previous == "" || previous == currency {
+				continue
+			}
+
+			query := tx.Table(cc.table).Where("currency = ?", previous)
+			if cc.where != "" {
+				query = query.Where(cc.where)
+			}
+			result := query.Update("currency", currency)
+			if result.Error != nil {
+				return result.Error
+			}
+			logger.Log.WithFields(map[string]interface{}{
+				"table": cc.table,
+				"from":  previous,
+				"to":    currency,
+				"rows":  result.RowsAffected,
+			}).Info("Moved rows to the new store currency")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, cc := range currencyColumns {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(cc.model); err != nil {
+			return err
+		}
+		field := stmt.Schema.LookUpField("Currency")
+		if field == nil {
+			return fmt.Errorf("%s has no currency field", cc.table)
+		}
+		field.DefaultValue = currency
+		field.DefaultValueInterface = currency
+	}
+	return nil
+}
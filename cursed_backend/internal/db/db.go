@@ -151,9 +151,51 @@ func InitDB(cfg *config.Config) {
 	logger.Log.Info("Database ping successful")
 
 	logger.Log.Info("Running database migrations")
-	if err = GormDB.AutoMigrate(&models.User{}, &models.Pet{}, &models.Product{}); err != nil {
+	if err = migrateMoneyColumns(GormDB); err != nil {
+		logger.Log.WithError(err).Fatal("Failed to migrate money columns")
+	}
+	if err = dropLegacyIndexes(GormDB); err != nil {
+		logger.Log.WithError(err).Fatal("Failed to drop legacy indexes")
+	}
+	if err = useStoreCurrency(GormDB, cfg.Currency); err != nil {
+		logger.Log.WithError(err).Fatal("Failed to apply store currency")
+	}
+	if err = GormDB.AutoMigrate(
+		&models.User{},
+		&models.Pet{},
+		&models.Product{},
+		&models.ProductVariant{},
+		&models.Order{},
+		&models.OrderItem{},
+		&models.CartItem{},
+		&models.WalletTransaction{},
+		&models.ReturnRequest{},
+		&models.Promotion{},
+		&models.IdempotencyKey{},
+		&models.AdoptionApplication{},
+		&models.WaitlistEntry{},
+		&models.Supplier{},
+		&models.PurchaseOrder{},
+		&models.PurchaseOrderLine{},
+		&models.StockMovement{},
+		&models.Bundle{},
+		&models.BundleItem{},
+		&models.Favorite{},
+		&models.Review{},
+		&models.PetTransfer{},
+		&models.Subscription{},
+		&models.LoyaltyTransaction{},
+		&models.GiftCard{},
+		&models.GiftCardTransaction{},
+		&models.PriceChange{},
+		&models.FlashSale{},
+		&models.FlashSaleItem{},
+	); err != nil {
 		logger.Log.WithError(err).Fatal("Failed to run migrations")
 	}
+	if err = seedOpeningStockMovements(GormDB); err != nil {
+		logger.Log.WithError(err).Fatal("Failed to seed opening stock movements")
+	}
 	logger.Log.Info("Database migrations completed")
 
 	logger.Log.Info("Database connected and migrated successfully")
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"cursed_backend/internal/logger"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// moneyColumns were stored as floating point major units before amounts
+// moved to integer minor units (models.Money).
+var moneyColumns = []struct{ table, column string }{
+	{"users", "balance"},
+	{"pets", "price"},
+	{"products", "price"},
+	{"orders", "total"},
+	{"order_items", "list_price"},
+	{"order_items", "discount"},
+	{"order_items", "unit_price"},
+	{"order_items", "subtotal"},
+	{"wallet_transactions", "amount"},
+	{"wallet_transactions", "balance_after"},
+	{"return_requests", "refund_amount"},
+	{"promotions", "amount_off"},
+}
+
+// migrateMoneyColumns converts legacy float money columns to bigint cents.
+// It must run before AutoMigrate, which would change the type without
+// scaling the values. Columns already converted or not yet created are skipped.
+func migrateMoneyColumns(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, mc := range moneyColumns {
+			var dataType string
+			if err := tx.Raw("SELECT data_type FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = ? AND column_name = ?",
+				mc.table, mc.column).Scan(&dataType).Error; err != nil {
+				return err
+			}
+			if dataType != "double precision" && dataType != "real" && dataType != "numeric" {
+				continue
+			}
+
+			logger.Log.WithFields(map[string]interface{}{
+				"table":  mc.table,
+				"column": mc.column,
+			}).Info("Converting money column to minor units")
+			stmt := fmt.Sprintf(`ALTER TABLE %[1]q ALTER COLUMN %[2]q DROP DEFAULT, ALTER COLUMN %[2]q TYPE bigint USING ROUND(%[2]q * 100)::bigint, ALTER COLUMN %[2]q SET DEFAULT 0`,
+				mc.table, mc.column)
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"cursed_backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// seedOpeningStockMovements gives store products that have stock but no
+// ledger history an opening balance entry, so the ledger reconciles with
+// stock set before movements were recorded.
+func seedOpeningStockMovements(db *gorm.DB) error {
+	return db.Exec(`INSERT INTO stock_movements (product_id, delta, reason, actor_id, created_at)
+		SELECT p.id, p.stock, ?, 0, NOW() FROM products p
+		WHERE p.owner_id = 0 AND p.stock <> 0
+		AND NOT EXISTS (SELECT 1 FROM stock_movements m WHERE m.product_id = p.id)`,
+		models.StockReasonOpening).Error
+}
+
+// dropLegacyIndexes removes indexes that were replaced under a new name, so
+// AutoMigrate can create their successors without the old constraint lingering.
+func dropLegacyIndexes(db *gorm.DB) error {
+	legacy := []struct {
+		model interface{}
+		name  string
+	}{
+		// Cart lines became unique per variant
+		{&models.CartItem{}, "idx_cart_user_item"},
+	}
+	for _, l := range legacy {
+		if db.Migrator().HasIndex(l.model, l.name) {
+			if err := db.Migrator().DropIndex(l.model, l.name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
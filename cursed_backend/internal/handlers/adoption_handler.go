@@ -0,0 +1,331 @@
+package handlers
+
+import (
+	"cursed_backend/internal/db"
+	"cursed_backend/internal/logger"
+	"cursed_backend/internal/models"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/microcosm-cc/bluemonday"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errAdoptionRequired   = &purchaseError{http.StatusConflict, "Pets are adopted through an adoption application"}
+	errAdoptionNotPending = &purchaseError{http.StatusConflict, "Adoption application already decided"}
+	errAdoptionMissing    = &purchaseError{http.StatusNotFound, "Adoption application not found"}
+	errAdoptionDuplicate  = &purchaseError{http.StatusConflict, "You already have a pending application for this pet"}
+)
+
+var adoptionStatuses = []string{models.AdoptionStatusPending, models.AdoptionStatusApproved, models.AdoptionStatusRejected, models.AdoptionStatusWithdrawn}
+
+func ApplyForAdoption(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var req struct {
+		HousingType   string `json:"housingType" validate:"required,oneof=house apartment other"`
+		HouseholdSize int    `json:"householdSize" validate:"required,min=1,max=20"`
+		HasChildren   bool   `json:"hasChildren"`
+		HasOtherPets  bool   `json:"hasOtherPets"`
+		Experience    string `json:"experience" validate:"required,oneof=none some experienced"`
+		Motivation    string `json:"motivation" validate:"required,min=20,max=2000"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	userID := c.GetUint("user_id")
+
+	var pet models.Pet
+	if err := db.GormDB.First(&pet, "id = ? AND owner_id = 0", id).Error; err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: errPetUnavailable.message})
+		return
+	}
+
+	var pending int64
+	if err := db.GormDB.Model(&models.AdoptionApplication{}).
+		Where("user_id = ? AND pet_id = ? AND status = ?", userID, pet.ID, models.AdoptionStatusPending).
+		Count(&pending).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to check applications"})
+		return
+	}
+	if pending > 0 {
+		writePurchaseError(c, errAdoptionDuplicate, "")
+		return
+	}
+
+	app := models.AdoptionApplication{
+		UserID:        userID,
+		PetID:         pet.ID,
+		HousingType:   req.HousingType,
+		HouseholdSize: req.HouseholdSize,
+		HasChildren:   req.HasChildren,
+		HasOtherPets:  req.HasOtherPets,
+		Experience:    req.Experience,
+		Motivation:    bluemonday.UGCPolicy().Sanitize(req.Motivation),
+		Status:        models.AdoptionStatusPending,
+	}
+	// The partial unique index catches a concurrent duplicate the count missed
+	if err := db.GormDB.Create(&app).Error; err != nil {
+		writePurchaseError(c, errAdoptionDuplicate, "")
+		return
+	}
+
+	logger.AuditLog("adoption_applied", userID, c.ClientIP(), nil)
+	app.Pet = &pet
+	c.JSON(http.StatusCreated, models.APIResponse{Success: true, Data: app})
+}
+
+func MyAdoptions(c *gin.Context) {
+	listAdoptions(c, c.GetUint("user_id"))
+}
+
+func MyAdoption(c *gin.Context) {
+	getAdoption(c, c.GetUint("user_id"))
+}
+
+func GetAdoptions(c *gin.Context) {
+	listAdoptions(c, 0)
+}
+
+func GetAdoption(c *gin.Context) {
+	getAdoption(c, 0)
+}
+
+// listAdoptions answers with applications filtered by ?status and ?pet_id,
+// limited to userID's own unless it is 0.
+func listAdoptions(c *gin.Context, userID uint) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var apps []models.AdoptionApplication
+	query := db.GormDB
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	if status := c.Query("status"); status != "" {
+		if !slices.Contains(adoptionStatuses, status) {
+			c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Invalid status"})
+			return
+		}
+		query = query.Where("status = ?", status)
+	}
+	if petIDStr := c.Query("pet_id"); petIDStr != "" {
+		petID, err := strconv.ParseUint(petIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Invalid pet ID"})
+			return
+		}
+		query = query.Where("pet_id = ?", petID)
+	}
+
+	if err := query.Order("created_at DESC").Find(&apps).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch adoption applications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: apps})
+}
+
+// getAdoption answers with one application and its pet, limited to userID's
+// own unless it is 0.
+func getAdoption(c *gin.Context, userID uint) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	query := db.GormDB
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var app models.AdoptionApplication
+	if err := query.First(&app, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: errAdoptionMissing.message})
+		return
+	}
+	var pet models.Pet
+	if err := db.GormDB.First(&pet, app.PetID).Error; err == nil {
+		app.Pet = &pet
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: app})
+}
+
+func WithdrawAdoption(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	userID := c.GetUint("user_id")
+	result := db.GormDB.Model(&models.AdoptionApplication{}).
+		Where("id = ? AND user_id = ? AND status = ?", id, userID, models.AdoptionStatusPending).
+		Updates(map[string]interface{}{"status": models.AdoptionStatusWithdrawn, "decided_at": time.Now()})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Withdrawal failed"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, models.APIResponse{Success: false, Message: "Adoption application not found or already decided"})
+		return
+	}
+
+	logger.AuditLog("adoption_withdrawn", userID, c.ClientIP(), nil)
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Application withdrawn"})
+}
+
+// ApproveAdoption hands the pet to the applicant the same way BuyPet does:
+// the pet row is locked, an order is recorded and the applicant's wallet is
+// charged. Other pending applications for the pet are rejected.
+func ApproveAdoption(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason" validate:"omitempty,max=500"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	reviewerID := c.GetUint("user_id")
+
+	var app models.AdoptionApplication
+	var pet *models.Pet
+	err := db.GormDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&app, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errAdoptionMissing
+			}
+			return err
+		}
+		if app.Status != models.AdoptionStatusPending {
+			return errAdoptionNotPending
+		}
+
+		book, err := newPriceBook(tx, "")
+		if err != nil {
+			return err
+		}
+		var item models.OrderItem
+		pet, item, err = purchasePet(tx, book, app.UserID, app.PetID)
+		if err != nil {
+			return err
+		}
+		order, err := createOrder(tx, app.UserID, []models.OrderItem{item})
+		if err != nil {
+			return err
+		}
+		if err := chargeOrder(tx, order); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		app.Status = models.AdoptionStatusApproved
+		app.ReviewerID = reviewerID
+		app.DecisionReason = req.Reason
+		app.OrderID = order.ID
+		app.DecidedAt = &now
+		if err := tx.Save(&app).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.AdoptionApplication{}).
+			Where("pet_id = ? AND status = ? AND id <> ?", app.PetID, models.AdoptionStatusPending, app.ID).
+			Updates(map[string]interface{}{
+				"status":          models.AdoptionStatusRejected,
+				"reviewer_id":     reviewerID,
+				"decision_reason": "Pet adopted by another applicant",
+				"decided_at":      now,
+			}).Error
+	})
+	if err != nil {
+		writePurchaseError(c, err, "Adoption approval failed")
+		return
+	}
+
+	logger.AuditLog("adoption_approved", app.UserID, c.ClientIP(), nil)
+	pet.OwnerID = app.UserID
+	app.Pet = pet
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Adoption approved", Data: app})
+}
+
+func RejectAdoption(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason" validate:"required,min=3,max=500"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	result := db.GormDB.Model(&models.AdoptionApplication{}).
+		Where("id = ? AND status = ?", id, models.AdoptionStatusPending).
+		Updates(map[string]interface{}{
+			"status":          models.AdoptionStatusRejected,
+			"reviewer_id":     c.GetUint("user_id"),
+			"decision_reason": req.Reason,
+			"decided_at":      time.Now(),
+		})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Adoption rejection failed"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, models.APIResponse{Success: false, Message: "Adoption application not found or already decided"})
+		return
+	}
+
+	var app models.AdoptionApplication
+	if err := db.GormDB.First(&app, id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to refresh adoption application"})
+		return
+	}
+
+	logger.AuditLog("adoption_rejected", app.UserID, c.ClientIP(), nil)
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Adoption rejected", Data: app})
+}
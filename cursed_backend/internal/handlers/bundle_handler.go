@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"cursed_backend/internal/db"
+	"cursed_backend/internal/logger"
+	"cursed_backend/internal/models"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errBundleUnavailable = &purchaseError{http.StatusBadRequest, "Bundle not found or not available"}
+
+// bundleAvailability is how many copies of the bundle current component
+// stock can make up. Components that are no longer on sale make it 0.
+func bundleAvailability(query *gorm.DB, bundle *models.Bundle) (int, error) {
+	available := -1
+	for i, item := range bundle.Items {
+		var product models.Product
+		if err := query.First(&product, "id = ? AND owner_id = 0", item.ProductID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return 0, nil
+			}
+			return 0, err
+		}
+		stock := product.Stock
+		if item.VariantID != 0 {
+			var variant models.ProductVariant
+			if err := query.First(&variant, "id = ? AND product_id = ?", item.VariantID, product.ID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return 0, nil
+				}
+				return 0, err
+			}
+			stock = variant.Stock
+		}
+		hideStaffFields(&product)
+		bundle.Items[i].Product = &product
+		if n := stock / item.Quantity; available < 0 || n < available {
+			available = n
+		}
+	}
+	if available < 0 {
+		return 0, nil
+	}
+	return available, nil
+}
+
+// validateBundle checks the bundle fields and that every component is a store
+// product, with a variant exactly when the product has variants.
+func validateBundle(bundle *models.Bundle) error {
+	validate := validator.New()
+	if err := validate.Struct(bundle); err != nil {
+		return &purchaseError{http.StatusBadRequest, "Validation failed: " + err.Error()}
+	}
+	for _, item := range bundle.Items {
+		var product models.Product
+		if err := db.GormDB.First(&product, "id = ? AND owner_id = 0", item.ProductID).Error; err != nil {
+			return &purchaseError{http.StatusBadRequest, "Bundle items must reference store products"}
+		}
+		if _, err := findVariant(db.GormDB, &product, item.VariantID, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func GetBundles(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var bundles []models.Bundle
+	query := db.GormDB.Preload("Items")
+	role := c.GetString("role")
+	if role != "manager" && role != "admin" {
+		query = query.Where("active = ?", true)
+	}
+	if err := query.Order("id").Find(&bundles).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch bundles"})
+		return
+	}
+	for i := range bundles {
+		available, err := bundleAvailability(db.GormDB, &bundles[i])
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to check bundle stock"})
+			return
+		}
+		bundles[i].Available = available
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: bundles})
+}
+
+func CreateBundle(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	bundle := models.Bundle{Active: true}
+	if err := c.ShouldBindJSON(&bundle); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	bundle.ID = 0
+	for i := range bundle.Items {
+		bundle.Items[i].ID = 0
+	}
+	currency, err := normalizeCurrency(bundle.Currency)
+	if err != nil {
+		writePurchaseError(c, err, "Creation failed")
+		return
+	}
+	bundle.Currency = currency
+	if err := validateBundle(&bundle); err != nil {
+		writePurchaseError(c, err, "Creation failed")
+		return
+	}
+
+	if err := db.GormDB.Create(&bundle).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Creation failed"})
+		return
+	}
+	c.JSON(http.StatusCreated, models.APIResponse{Success: true, Data: bundle})
+}
+
+// UpdateBundle replaces the bundle's fields and its component list.
+func UpdateBundle(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var bundle models.Bundle
+	if err := db.GormDB.First(&bundle, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: "Bundle not found"})
+		return
+	}
+	bundle.Items = nil
+	if err := c.ShouldBindJSON(&bundle); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	bundle.ID = uint(id)
+	for i := range bundle.Items {
+		bundle.Items[i].ID = 0
+		bundle.Items[i].BundleID = bundle.ID
+	}
+	currency, err := normalizeCurrency(bundle.Currency)
+	if err != nil {
+		writePurchaseError(c, err, "Update failed")
+		return
+	}
+	bundle.Currency = currency
+	if err := validateBundle(&bundle); err != nil {
+		writePurchaseError(c, err, "Update failed")
+		return
+	}
+
+	err = db.GormDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("bundle_id = ?", bundle.ID).Delete(&models.BundleItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&bundle.Items).Error; err != nil {
+			return err
+		}
+		return tx.Omit("Items", "created_at").Save(&bundle).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Update failed"})
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: bundle})
+}
+
+func DeleteBundle(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	result := db.GormDB.Delete(&models.Bundle{}, id)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Delete failed"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: "Bundle not found"})
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Bundle deleted"})
+}
+
+// purchaseBundle takes quantity bundles' worth of every component out of
+// stock and returns the order lines: the bundle line carrying the price and a
+// zero-priced line per component. Components are locked in product order, as
+// checkout does. It must run inside a transaction.
+func purchaseBundle(tx *gorm.DB, book *priceBook, hooks *afterCommit, userID, bundleID uint, quantity int) (*models.Bundle, []models.OrderItem, error) {
+	if quantity < 1 {
+		return nil, nil, errInvalidQuantity
+	}
+
+	var bundle models.Bundle
+	if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).First(&bundle, "id = ? AND active = ?", bundleID, true).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errBundleUnavailable
+		}
+		return nil, nil, err
+	}
+	if !storeCurrency(bundle.Currency) {
+		return nil, nil, errCurrencyMismatch
+	}
+	if err := tx.Where("bundle_id = ?", bundle.ID).Find(&bundle.Items).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(bundle.Items) == 0 {
+		return nil, nil, errBundleUnavailable
+	}
+	components := append([]models.BundleItem(nil), bundle.Items...)
+	sort.Slice(components, func(i, j int) bool {
+		if components[i].ProductID != components[j].ProductID {
+			return components[i].ProductID < components[j].ProductID
+		}
+		return components[i].VariantID < components[j].VariantID
+	})
+
+	items := []models.OrderItem{{
+		ItemType:  models.ItemTypeBundle,
+		ItemID:    bundle.ID,
+		Name:      bundle.Name,
+		ListPrice: bundle.Price,
+		UnitPrice: bundle.Price,
+		Quantity:  quantity,
+	}}
+	for _, comp := range components {
+		_, item, err := purchaseProduct(tx, book, hooks, userID, comp.ProductID, comp.VariantID, comp.Quantity*quantity)
+		if err != nil {
+			return nil, nil, err
+		}
+		// The bundle line carries the price; components are listed at full discount
+		item.BundleID = bundle.ID
+		item.Discount = item.ListPrice
+		item.UnitPrice = 0
+		item.PromotionID = 0
+		items = append(items, item)
+	}
+	return &bundle, items, nil
+}
+
+func BuyBundle(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var req struct {
+		Quantity     int    `json:"quantity" validate:"omitempty,min=1"`
+		RedeemPoints int64  `json:"redeemPoints" validate:"gte=0"`
+		GiftCardCode string `json:"giftCardCode" validate:"omitempty,max=32"`
+	}
+	// The body is optional; an empty one buys a single bundle
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	userID := c.GetUint("user_id")
+	tx := db.GormDB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Bundles have their own price, so promotions are not applied
+	book, err := newPriceBook(tx, "")
+	if err != nil {
+		writePurchaseError(c, err, "Failed to load promotions")
+		tx.Rollback()
+		return
+	}
+
+	var hooks afterCommit
+	bundle, items, err := purchaseBundle(tx, book, &hooks, userID, uint(id), req.Quantity)
+	if err != nil {
+		writePurchaseError(c, err, "Purchase failed")
+		tx.Rollback()
+		return
+	}
+
+	order, err := createOrder(tx, userID, items)
+	if err != nil {
+		writePurchaseError(c, err, "Failed to record order")
+		tx.Rollback()
+		return
+	}
+
+	if err := redeemLoyaltyPoints(tx, order, req.RedeemPoints); err != nil {
+		writePurchaseError(c, err, "Failed to redeem points")
+		tx.Rollback()
+		return
+	}
+	if err := redeemGiftCard(tx, order, req.GiftCardCode); err != nil {
+		writePurchaseError(c, err, "Failed to redeem gift card")
+		tx.Rollback()
+		return
+	}
+
+	if err := chargeOrder(tx, order); err != nil {
+		writePurchaseError(c, err, "Payment failed")
+		tx.Rollback()
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Transaction commit failed"})
+		return
+	}
+	hooks.run()
+
+	logger.AuditLog("bundle_purchased", userID, c.ClientIP(), nil)
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Bundle purchased", Data: gin.H{"bundle": bundle, "order": order}})
+}
@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"cursed_backend/internal/db"
+	"cursed_backend/internal/logger"
+	"cursed_backend/internal/models"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func GetCart(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var items []models.CartItem
+	userID := c.GetUint("user_id")
+
+	if err := db.GormDB.Where("user_id = ?", userID).Order("created_at").Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch cart"})
+		return
+	}
+
+	// Attach current store data; items sold or removed since they were added stay nil
+	policy := bluemonday.UGCPolicy()
+	var total models.Money
+	for i := range items {
+		switch items[i].ItemType {
+		case models.ItemTypePet:
+			var pet models.Pet
+			if err := db.GormDB.First(&pet, "id = ? AND owner_id = 0", items[i].ItemID).Error; err == nil {
+				pet.Name = policy.Sanitize(pet.Name)
+				pet.Description = policy.Sanitize(pet.Description)
+				items[i].Pet = &pet
+				total += pet.Price
+			}
+		case models.ItemTypeProduct:
+			var product models.Product
+			if err := db.GormDB.First(&product, "id = ? AND owner_id = 0", items[i].ItemID).Error; err != nil {
+				continue
+			}
+			price := product.Price
+			if items[i].VariantID != 0 {
+				var variant models.ProductVariant
+				if err := db.GormDB.First(&variant, "id = ? AND product_id = ?", items[i].VariantID, product.ID).Error; err != nil {
+					continue
+				}
+				items[i].Variant = &variant
+				price = variant.Price
+			}
+			hideStaffFields(&product)
+			items[i].Product = &product
+			total += price.Times(items[i].Quantity)
+		}
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: gin.H{"items": items, "total": total}})
+}
+
+func AddToCart(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var req struct {
+		ItemType  string `json:"itemType" validate:"required,oneof=pet product"`
+		ItemID    uint   `json:"itemId" validate:"required"`
+		VariantID uint   `json:"variantId"`
+		Quantity  int    `json:"quantity" validate:"omitempty,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
+	userID := c.GetUint("user_id")
+	var item models.CartItem
+	if req.ItemType == models.ItemTypePet {
+		req.VariantID = 0
+	}
+	err := db.GormDB.Where("user_id = ? AND item_type = ? AND item_id = ? AND variant_id = ?", userID, req.ItemType, req.ItemID, req.VariantID).First(&item).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch cart"})
+		return
+	}
+	quantity := item.Quantity + req.Quantity
+
+	switch req.ItemType {
+	case models.ItemTypePet:
+		if appConfig.AdoptionRequired {
+			writePurchaseError(c, errAdoptionRequired, "")
+			return
+		}
+		if quantity > 1 {
+			c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Pet is already in cart"})
+			return
+		}
+		var pet models.Pet
+		if err := db.GormDB.First(&pet, "id = ? AND owner_id = 0", req.ItemID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Pet not found or already owned"})
+			return
+		}
+		if pet.HeldByOther(userID, time.Now()) {
+			c.JSON(http.StatusConflict, models.APIResponse{Success: false, Message: errPetReserved.message})
+			return
+		}
+	case models.ItemTypeProduct:
+		var product models.Product
+		if err := db.GormDB.First(&product, "id = ? AND owner_id = 0", req.ItemID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Product not found or not available"})
+			return
+		}
+		variant, err := findVariant(db.GormDB, &product, req.VariantID, false)
+		if err != nil {
+			writePurchaseError(c, err, "Failed to check variant")
+			return
+		}
+		available := product.Stock
+		if variant != nil {
+			available = variant.Stock
+		}
+		if quantity > available {
+			c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Not enough stock"})
+			return
+		}
+	}
+
+	item.UserID = userID
+	item.ItemType = req.ItemType
+	item.ItemID = req.ItemID
+	item.VariantID = req.VariantID
+	item.Quantity = quantity
+	if err := db.GormDB.Save(&item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to update cart"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Added to cart", Data: item})
+}
+
+func UpdateCartItem(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var req struct {
+		Quantity int `json:"quantity" validate:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	userID := c.GetUint("user_id")
+	var item models.CartItem
+	if err := db.GormDB.First(&item, "id = ? AND user_id = ?", id, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: "Cart item not found"})
+		return
+	}
+	if item.ItemType == models.ItemTypePet && req.Quantity != 1 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Pets can only be bought one at a time"})
+		return
+	}
+
+	if err := db.GormDB.Model(&item).Update("quantity", req.Quantity).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to update cart"})
+		return
+	}
+	item.Quantity = req.Quantity
+
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: item})
+}
+
+func RemoveFromCart(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	userID := c.GetUint("user_id")
+	result := db.GormDB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.CartItem{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to update cart"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: "Cart item not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Removed from cart"})
+}
+
+// Checkout buys everything in the cart in one transaction: either every item
+// is purchased and the cart is emptied, or nothing changes.
+func Checkout(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var req struct {
+		PromoCode    string `json:"promoCode" validate:"omitempty,max=50"`
+		RedeemPoints int64  `json:"redeemPoints" validate:"gte=0"`
+		GiftCardCode string `json:"giftCardCode" validate:"omitempty,max=32"`
+	}
+	// The body is optional
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	tx := db.GormDB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Lock the cart itself so two concurrent checkouts cannot buy it twice.
+	// Rows are walked in a fixed order so store rows are always locked in the same sequence.
+	var cartItems []models.CartItem
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).Order("item_type, item_id, variant_id").Find(&cartItems).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch cart"})
+		tx.Rollback()
+		return
+	}
+	if len(cartItems) == 0 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Cart is empty"})
+		tx.Rollback()
+		return
+	}
+
+	book, err := newPriceBook(tx, req.PromoCode)
+	if err != nil {
+		writePurchaseError(c, err, "Failed to load promotions")
+		tx.Rollback()
+		return
+	}
+
+	var hooks afterCommit
+	orderItems := make([]models.OrderItem, 0, len(cartItems))
+	for _, ci := range cartItems {
+		var item models.OrderItem
+		var err error
+		switch ci.ItemType {
+		case models.ItemTypePet:
+			if appConfig.AdoptionRequired {
+				err = errAdoptionRequired
+				break
+			}
+			_, item, err = purchasePet(tx, book, userID, ci.ItemID)
+		case models.ItemTypeProduct:
+			_, item, err = purchaseProduct(tx, book, &hooks, userID, ci.ItemID, ci.VariantID, ci.Quantity)
+			if err == nil {
+				err = applyFlashSale(tx, book, userID, &item)
+			}
+		default:
+			err = &purchaseError{http.StatusBadRequest, "Unknown cart item type"}
+		}
+		if err != nil {
+			logger.Log.WithFields(logrus.Fields{"user_id": userID, "cart_item_id": ci.ID}).WithError(err).Warn("Checkout aborted")
+			writePurchaseError(c, err, "Checkout failed")
+			tx.Rollback()
+			return
+		}
+		orderItems = append(orderItems, item)
+	}
+
+	order, err := createOrder(tx, userID, orderItems)
+	if err != nil {
+		writePurchaseError(c, err, "Failed to record order")
+		tx.Rollback()
+		return
+	}
+
+	if err := redeemLoyaltyPoints(tx, order, req.RedeemPoints); err != nil {
+		writePurchaseError(c, err, "Failed to redeem points")
+		tx.Rollback()
+		return
+	}
+	if err := redeemGiftCard(tx, order, req.GiftCardCode); err != nil {
+		writePurchaseError(c, err, "Failed to redeem gift card")
+		tx.Rollback()
+		return
+	}
+
+	if err := chargeOrder(tx, order); err != nil {
+		writePurchaseError(c, err, "Payment failed")
+		tx.Rollback()
+		return
+	}
+
+	if err := tx.Where("user_id = ?", userID).Delete(&models.CartItem{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to clear cart"})
+		tx.Rollback()
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Transaction commit failed"})
+		return
+	}
+	hooks.run()
+
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Checkout complete", Data: order})
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"cursed_backend/internal/config"
+	"cursed_backend/internal/notify"
+)
+
+// appConfig holds the policy settings handlers read at request time. It is
+// set once by Configure during router setup.
+var appConfig = &config.Config{}
+
+// notifier delivers customer notifications; it logs them unless
+// SetNotifier installs something else.
+var notifier notify.Notifier = notify.LogNotifier{}
+
+func Configure(cfg *config.Config) {
+	appConfig = cfg
+}
+
+func SetNotifier(n notify.Notifier) {
+	notifier = n
+}
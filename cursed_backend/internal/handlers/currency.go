@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var errInvalidCurrency = &purchaseError{http.StatusBadRequest, "Invalid currency code"}
+
+// normalizeCurrency upper-cases an ISO 4217 code, defaulting to the store
+// currency when empty.
+func normalizeCurrency(code string) (string, error) {
+	if code == "" {
+		return appConfig.Currency, nil
+	}
+	code = strings.ToUpper(code)
+	if err := validator.New().Var(code, "iso4217"); err != nil {
+		return "", errInvalidCurrency
+	}
+	return code, nil
+}
+
+// storeCurrency reports whether prices in code can be charged to wallets,
+// which are always kept in the store currency.
+func storeCurrency(code string) bool {
+	return code == "" || strings.EqualFold(code, appConfig.Currency)
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"cursed_backend/internal/db"
+	"cursed_backend/internal/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm/clause"
+)
+
+type favoriteRequest struct {
+	ItemType string `json:"itemType" validate:"required,oneof=pet product"`
+	ItemID   uint   `json:"itemId" validate:"required"`
+}
+
+func bindFavorite(c *gin.Context) (favoriteRequest, bool) {
+	var req favoriteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return req, false
+	}
+	if err := validator.New().Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return req, false
+	}
+	return req, true
+}
+
+func AddFavorite(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	req, ok := bindFavorite(c)
+	if !ok {
+		return
+	}
+	userID := c.GetUint("user_id")
+
+	// Only store items can be saved; owned ones are not for sale
+	var found int64
+	var err error
+	if req.ItemType == models.ItemTypePet {
+		err = db.GormDB.Model(&models.Pet{}).Where("id = ? AND owner_id = 0", req.ItemID).Count(&found).Error
+	} else {
+		err = db.GormDB.Model(&models.Product{}).Where("id = ? AND owner_id = 0", req.ItemID).Count(&found).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to add favorite"})
+		return
+	}
+	if found == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: "Item not found"})
+		return
+	}
+
+	fav := models.Favorite{UserID: userID, ItemType: req.ItemType, ItemID: req.ItemID}
+	result := db.GormDB.Clauses(clause.OnConflict{DoNothing: true}).Create(&fav)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to add favorite"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		if err := db.GormDB.First(&fav, "user_id = ? AND item_type = ? AND item_id = ?", userID, req.ItemType, req.ItemID).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to add favorite"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Added to favorites", Data: fav})
+}
+
+func RemoveFavorite(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	req, ok := bindFavorite(c)
+	if !ok {
+		return
+	}
+
+	result := db.GormDB.Where("user_id = ? AND item_type = ? AND item_id = ?", c.GetUint("user_id"), req.ItemType, req.ItemID).Delete(&models.Favorite{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to remove favorite"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: "Item is not in favorites"})
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Removed from favorites"})
+}
+
+func MyFavorites(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var favs []models.Favorite
+	if err := db.GormDB.Where("user_id = ?", c.GetUint("user_id")).Order("created_at DESC").Find(&favs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch favorites"})
+		return
+	}
+
+	var petIDs, productIDs []uint
+	for _, f := range favs {
+		if f.ItemType == models.ItemTypePet {
+			petIDs = append(petIDs, f.ItemID)
+		} else {
+			productIDs = append(productIDs, f.ItemID)
+		}
+	}
+	pets := map[uint]*models.Pet{}
+	if len(petIDs) > 0 {
+		var rows []models.Pet
+		if err := db.GormDB.Where("id IN ?", petIDs).Find(&rows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch favorites"})
+			return
+		}
+		for i := range rows {
+			pets[rows[i].ID] = &rows[i]
+		}
+	}
+	products := map[uint]*models.Product{}
+	if len(productIDs) > 0 {
+		var rows []models.Product
+		if err := db.GormDB.Scopes(preloadVariants).Where("id IN ?", productIDs).Find(&rows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch favorites"})
+			return
+		}
+		for i := range rows {
+			hideStaffFields(&rows[i])
+			products[rows[i].ID] = &rows[i]
+		}
+	}
+
+	// Favorites whose item has been deleted are left out
+	out := make([]models.Favorite, 0, len(favs))
+	for _, f := range favs {
+		if f.ItemType == models.ItemTypePet {
+			f.Pet = pets[f.ItemID]
+		} else {
+			f.Product = products[f.ItemID]
+		}
+		if f.Pet == nil && f.Product == nil {
+			continue
+		}
+		out = append(out, f)
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: out})
+}
+
+// favoriteSet returns which of ids the user has saved as favorites of itemType.
+func favoriteSet(userID uint, itemType string, ids []uint) (map[uint]bool, error) {
+	set := map[uint]bool{}
+	if len(ids) == 0 {
+		return set, nil
+	}
+	var saved []uint
+	if err := db.GormDB.Model(&models.Favorite{}).
+		Where("user_id = ? AND item_type = ? AND item_id IN ?", userID, itemType, ids).
+		Pluck("item_id", &saved).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range saved {
+		set[id] = true
+	}
+	return set, nil
+}
@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"cursed_backend/internal/db"
+	"cursed_backend/internal/logger"
+	"cursed_backend/internal/models"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errFlashSaleMissing  = &purchaseError{http.StatusNotFound, "Flash sale not found"}
+	errFlashSaleSoldOut  = &purchaseError{http.StatusConflict, "Not enough units left at the flash sale price"}
+	errFlashSaleLimit    = &purchaseError{http.StatusBadRequest, "Flash sale limit per customer exceeded"}
+	errFlashSaleOverlap  = &purchaseError{http.StatusConflict, "A product is already in another flash sale at that time"}
+	errFlashSaleHasSales = &purchaseError{http.StatusConflict, "Flash sale has sales; deactivate it instead"}
+)
+
+// runningFlashSaleItems selects items of flash sales that are active at now
+// and still have units left.
+func runningFlashSaleItems(tx *gorm.DB, now time.Time) *gorm.DB {
+	return tx.Model(&models.FlashSaleItem{}).
+		Joins("JOIN flash_sales ON flash_sales.id = flash_sale_items.flash_sale_id").
+		Where("flash_sales.active = ? AND flash_sales.starts_at <= ? AND flash_sales.ends_at > ?", true, now, now).
+		Where("flash_sale_items.sold_quantity < flash_sale_items.total_quantity")
+}
+
+// applyFlashSale reprices an order line from purchaseProduct at the running
+// flash sale price, if there is one and it beats the promotional price. The
+// sale item row is locked and its sold counter only moves while it stays
+// within the allotment, so concurrent buyers cannot oversell it. The store
+// product row is already locked by purchaseProduct, which serializes buyers
+// of the product and makes the per-customer check safe.
+func applyFlashSale(tx *gorm.DB, book *priceBook, userID uint, item *models.OrderItem) error {
+	var sale models.FlashSaleItem
+	err := runningFlashSaleItems(tx, book.now).
+		Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "flash_sale_items"}}).
+		Where("flash_sale_items.product_id = ? AND flash_sale_items.variant_id = ?", item.ItemID, item.VariantID).
+		Order("flash_sale_items.sale_price, flash_sale_items.id").
+		Select("flash_sale_items.*").
+		Take(&sale).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if sale.SalePrice >= item.UnitPrice {
+		return nil
+	}
+
+	var bought int64
+	if sale.PerUserLimit > 0 {
+		if err := tx.Model(&models.OrderItem{}).
+			Joins("JOIN orders ON orders.id = order_items.order_id").
+			Where("orders.user_id = ? AND order_items.flash_sale_item_id = ?", userID, sale.ID).
+			Select("COALESCE(SUM(order_items.quantity - order_items.returned), 0)").Scan(&bought).Error; err != nil {
+			return err
+		}
+	}
+	if err := checkFlashSaleItem(&sale, item.Quantity, int(bought)); err != nil {
+		return err
+	}
+
+	result := tx.Model(&models.FlashSaleItem{}).
+		Where("id = ? AND sold_quantity + ? <= total_quantity", sale.ID, item.Quantity).
+		Update("sold_quantity", gorm.Expr("sold_quantity + ?", item.Quantity))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errFlashSaleSoldOut
+	}
+
+	// The sale price replaces any promotion; they do not stack
+	item.Discount = item.ListPrice - sale.SalePrice
+	item.UnitPrice = sale.SalePrice
+	item.PromotionID = 0
+	item.FlashSaleItemID = sale.ID
+	return nil
+}
+
+// checkFlashSaleItem checks that quantity units are left in the sale item's
+// allotment and that the customer, who holds bought units from it that were
+// not returned, stays within the per-customer cap.
+func checkFlashSaleItem(sale *models.FlashSaleItem, quantity, bought int) error {
+	if quantity > sale.TotalQuantity-sale.SoldQuantity {
+		return errFlashSaleSoldOut
+	}
+	if sale.PerUserLimit > 0 && bought+quantity > sale.PerUserLimit {
+		return errFlashSaleLimit
+	}
+	return nil
+}
+
+// releaseFlashSaleUnits puts returned units of a flash sale line back into
+// the sale's allotment, as long as the sale is still running. Units returned
+// after the sale has ended are not sold at its price again.
+func releaseFlashSaleUnits(tx *gorm.DB, item *models.OrderItem, quantity int, now time.Time) error {
+	if item.FlashSaleItemID == 0 {
+		return nil
+	}
+	running := tx.Model(&models.FlashSale{}).Select("id").
+		Where("active = ? AND starts_at <= ? AND ends_at > ?", true, now, now)
+	return tx.Model(&models.FlashSaleItem{}).
+		Where("id = ? AND sold_quantity >= ? AND flash_sale_id IN (?)", item.FlashSaleItemID, quantity, running).
+		Update("sold_quantity", gorm.Expr("sold_quantity - ?", quantity)).Error
+}
+
+// attachFlashSales adds the running sale offers to store products, with the
+// time left for a countdown.
+func attachFlashSales(tx *gorm.DB, products []models.Product, now time.Time) error {
+	ids := make([]uint, 0, len(products))
+	for _, p := range products {
+		if p.OwnerID == 0 {
+			ids = append(ids, p.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var rows []struct {
+		models.FlashSaleItem
+		Name   string
+		EndsAt time.Time
+	}
+	if err := runningFlashSaleItems(tx, now).
+		Select("flash_sale_items.*, flash_sales.name, flash_sales.ends_at").
+		Where("flash_sale_items.product_id IN ?", ids).
+		Order("flash_sale_items.sale_price, flash_sale_items.id").
+		Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	offers := map[uint][]models.FlashSaleOffer{}
+	for _, r := range rows {
+		offers[r.ProductID] = append(offers[r.ProductID], models.FlashSaleOffer{
+			FlashSaleID:  r.FlashSaleID,
+			ItemID:       r.ID,
+			Name:         r.Name,
+			VariantID:    r.VariantID,
+			SalePrice:    r.SalePrice,
+			Remaining:    r.TotalQuantity - r.SoldQuantity,
+			PerUserLimit: r.PerUserLimit,
+			EndsAt:       r.EndsAt,
+			SecondsLeft:  int64(r.EndsAt.Sub(now).Seconds()),
+		})
+	}
+	for i := range products {
+		products[i].FlashSales = offers[products[i].ID]
+	}
+	return nil
+}
+
+// validateFlashSale checks the sale's fields and that every item is a store
+// product (or variant) priced above its sale price and not in another active
+// sale during the same window.
+func validateFlashSale(sale *models.FlashSale) error {
+	validate := validator.New()
+	if err := validate.Struct(sale); err != nil {
+		return &purchaseError{http.StatusBadRequest, "Validation failed: " + err.Error()}
+	}
+
+	type key struct{ product, variant uint }
+	seen := map[key]bool{}
+	for _, item := range sale.Items {
+		k := key{item.ProductID, item.VariantID}
+		if seen[k] {
+			return &purchaseError{http.StatusBadRequest, "A product appears more than once in the sale"}
+		}
+		seen[k] = true
+
+		var product models.Product
+		if err := db.GormDB.First(&product, "id = ? AND owner_id = 0", item.ProductID).Error; err != nil {
+			return &purchaseError{http.StatusBadRequest, "Flash sale items must reference store products"}
+		}
+		variant, err := findVariant(db.GormDB, &product, item.VariantID, false)
+		if err != nil {
+			return err
+		}
+		price := product.Price
+		if variant != nil {
+			price = variant.Price
+		}
+		if item.SalePrice >= price {
+			return &purchaseError{http.StatusBadRequest, "Sale price must be below the regular price of " + product.Name}
+		}
+
+		var overlapping int64
+		if err := db.GormDB.Model(&models.FlashSaleItem{}).
+			Joins("JOIN flash_sales ON flash_sales.id = flash_sale_items.flash_sale_id").
+			Where("flash_sale_items.product_id = ? AND flash_sale_items.variant_id = ?", item.ProductID, item.VariantID).
+			Where("flash_sales.id <> ? AND flash_sales.active = ?", sale.ID, true).
+			Where("flash_sales.starts_at < ? AND flash_sales.ends_at > ?", sale.EndsAt, sale.StartsAt).
+			Count(&overlapping).Error; err != nil {
+			return err
+		}
+		if overlapping > 0 {
+			return errFlashSaleOverlap
+		}
+	}
+	return nil
+}
+
+// GetFlashSales lists running and upcoming sales; managers also see ended
+// and inactive ones.
+func GetFlashSales(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	query := db.GormDB.Preload("Items")
+	role := c.GetString("role")
+	if role != "manager" && role != "admin" {
+		query = query.Where("active = ? AND ends_at > ?", true, time.Now())
+	}
+	var sales []models.FlashSale
+	if err := query.Order("starts_at, id").Find(&sales).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch flash sales"})
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: sales})
+}
+
+func CreateFlashSale(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	sale := models.FlashSale{Active: true}
+	if err := c.ShouldBindJSON(&sale); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	sale.ID = 0
+	for i := range sale.Items {
+		sale.Items[i].ID = 0
+		sale.Items[i].SoldQuantity = 0
+	}
+	if !sale.EndsAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "endsAt must be in the future"})
+		return
+	}
+	if err := validateFlashSale(&sale); err != nil {
+		writePurchaseError(c, err, "Creation failed")
+		return
+	}
+
+	if err := db.GormDB.Create(&sale).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Creation failed"})
+		return
+	}
+	logger.AuditLog("flash_sale_created", c.GetUint("user_id"), c.ClientIP(), nil)
+	c.JSON(http.StatusCreated, models.APIResponse{Success: true, Data: sale})
+}
+
+// UpdateFlashSale replaces the sale's fields and items. Once a sale has
+// started, its start time and items are kept: only the name, end time and
+// active flag can change.
+func UpdateFlashSale(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var sale models.FlashSale
+	err := db.GormDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&sale, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errFlashSaleMissing
+			}
+			return err
+		}
+		started := !sale.StartsAt.After(time.Now())
+		startsAt, items := sale.StartsAt, sale.Items
+
+		sale.Items = nil
+		if err := c.ShouldBindJSON(&sale); err != nil {
+			return &purchaseError{http.StatusBadRequest, err.Error()}
+		}
+		sale.ID = uint(id)
+		if started {
+			// Items and prices are locked once a sale starts; only the name,
+			// end time and active flag can change, so the items are not
+			// checked again against current prices or other sales.
+			sale.StartsAt, sale.Items = startsAt, items
+			if err := validator.New().StructExcept(&sale, "Items"); err != nil {
+				return &purchaseError{http.StatusBadRequest, "Validation failed: " + err.Error()}
+			}
+		} else {
+			for i := range sale.Items {
+				sale.Items[i].ID = 0
+				sale.Items[i].FlashSaleID = sale.ID
+				sale.Items[i].SoldQuantity = 0
+			}
+			if err := validateFlashSale(&sale); err != nil {
+				return err
+			}
+		}
+
+		if !started {
+			if err := tx.Where("flash_sale_id = ?", sale.ID).Delete(&models.FlashSaleItem{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&sale.Items).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Omit("Items", "created_at").Save(&sale).Error
+	})
+	if err != nil {
+		writePurchaseError(c, err, "Update failed")
+		return
+	}
+	logger.AuditLog("flash_sale_updated", c.GetUint("user_id"), c.ClientIP(), nil)
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: sale})
+}
+
+// DeleteFlashSale removes a sale nothing was sold in. Sales with orders are
+// kept for the order history and can only be deactivated.
+func DeleteFlashSale(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	err := db.GormDB.Transaction(func(tx *gorm.DB) error {
+		var sale models.FlashSale
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&sale, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errFlashSaleMissing
+			}
+			return err
+		}
+		var sold int64
+		if err := tx.Model(&models.FlashSaleItem{}).Where("flash_sale_id = ? AND sold_quantity > 0", sale.ID).Count(&sold).Error; err != nil {
+			return err
+		}
+		if sold > 0 {
+			return errFlashSaleHasSales
+		}
+		return tx.Delete(&sale).Error
+	})
+	if err != nil {
+		writePurchaseError(c, err, "Delete failed")
+		return
+	}
+	logger.AuditLog("flash_sale_deleted", c.GetUint("user_id"), c.ClientIP(), nil)
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Flash sale deleted"})
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"cursed_backend/internal/models"
+	"testing"
+)
+
+func TestCheckFlashSaleItem(t *testing.T) {
+	tests := []struct {
+		name     string
+		sale     models.FlashSaleItem
+		quantity int
+		bought   int
+		want     error
+	}{
+		{"units left", models.FlashSaleItem{TotalQuantity: 10, SoldQuantity: 4}, 6, 0, nil},
+		{"sold out", models.FlashSaleItem{TotalQuantity: 10, SoldQuantity: 10}, 1, 0, errFlashSaleSoldOut},
+		{"fewer units left than wanted", models.FlashSaleItem{TotalQuantity: 10, SoldQuantity: 8}, 3, 0, errFlashSaleSoldOut},
+		{"no cap", models.FlashSaleItem{TotalQuantity: 10}, 5, 5, nil},
+		{"within the cap", models.FlashSaleItem{TotalQuantity: 10, PerUserLimit: 2}, 1, 1, nil},
+		{"over the cap", models.FlashSaleItem{TotalQuantity: 10, PerUserLimit: 2}, 1, 2, errFlashSaleLimit},
+		{"one order over the cap", models.FlashSaleItem{TotalQuantity: 10, PerUserLimit: 2}, 3, 0, errFlashSaleLimit},
+		// bought is what the customer holds after returns, so a returned unit frees the cap
+		{"returned units free the cap", models.FlashSaleItem{TotalQuantity: 10, PerUserLimit: 2}, 1, 2 - 1, nil},
+		{"sold out wins over the cap", models.FlashSaleItem{TotalQuantity: 1, SoldQuantity: 1, PerUserLimit: 1}, 1, 1, errFlashSaleSoldOut},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkFlashSaleItem(&tt.sale, tt.quantity, tt.bought); err != tt.want {
+				t.Errorf("checkFlashSaleItem() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
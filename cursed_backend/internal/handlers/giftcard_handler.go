@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"cursed_backend/internal/db"
+	"cursed_backend/internal/logger"
+	"cursed_backend/internal/models"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errGiftCardInvalid  = &purchaseError{http.StatusBadRequest, "Gift card is invalid, expired or empty"}
+	errGiftCardMissing  = &purchaseError{http.StatusNotFound, "Gift card not found"}
+	errGiftCardCurrency = &purchaseError{http.StatusConflict, "Gift card is in a different currency"}
+)
+
+// generateGiftCardCode returns a random 80-bit code as four groups of four
+// base32 characters, e.g. ABCD-EFGH-IJKL-MNOP.
+func generateGiftCardCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	raw := base32.StdEncoding.EncodeToString(b)
+	return raw[0:4] + "-" + raw[4:8] + "-" + raw[8:12] + "-" + raw[12:16], nil
+}
+
+// normalizeGiftCardCode is how codes are compared: case, spaces and dashes
+// do not matter.
+func normalizeGiftCardCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func hashGiftCardCode(code string) string {
+	sum := sha256.Sum256([]byte(normalizeGiftCardCode(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+// redeemGiftCard pays as much of a freshly created order as the card covers.
+// The card row is locked for the rest of the transaction, so concurrent
+// purchases with the same card are serialized and cannot overspend it. Call
+// it between createOrder and chargeOrder.
+func redeemGiftCard(tx *gorm.DB, order *models.Order, code string) error {
+	if code == "" || order.Total <= 0 {
+		return nil
+	}
+
+	var card models.GiftCard
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&card, "code_hash = ?", hashGiftCardCode(code)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errGiftCardInvalid
+		}
+		return err
+	}
+	if !card.Usable(time.Now()) {
+		return errGiftCardInvalid
+	}
+	if card.Currency != order.Currency {
+		return errGiftCardCurrency
+	}
+
+	amount := min(card.Balance, order.Total)
+	if err := applyGiftCardChange(tx, &card, -amount, models.GiftCardTxRedeem, order.ID, order.UserID, order.UserID); err != nil {
+		return err
+	}
+	order.GiftCardID = card.ID
+	order.GiftCardAmount = amount
+	order.Total -= amount
+	return tx.Model(order).Updates(map[string]interface{}{
+		"total":            order.Total,
+		"gift_card_id":     order.GiftCardID,
+		"gift_card_amount": order.GiftCardAmount,
+	}).Error
+}
+
+// refundGiftCard puts amount back on the card an order was paid with. It
+// reports false without touching the card when the card has since been
+// deactivated or has expired, so the caller can refund elsewhere.
+func refundGiftCard(tx *gorm.DB, order *models.Order, amount models.Money, actorID uint) (bool, error) {
+	var card models.GiftCard
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&card, order.GiftCardID).Error; err != nil {
+		return false, err
+	}
+	if !card.Refundable(time.Now()) {
+		return false, nil
+	}
+	return true, applyGiftCardChange(tx, &card, amount, models.GiftCardTxRefund, order.ID, order.UserID, actorID)
+}
+
+// applyGiftCardChange moves the balance of a card locked by the caller and
+// appends the ledger entry.
+func applyGiftCardChange(tx *gorm.DB, card *models.GiftCard, amount models.Money, txType string, orderID, userID, actorID uint) error {
+	card.Balance += amount
+	if card.Balance < 0 {
+		return errGiftCardInvalid
+	}
+	if err := tx.Model(card).Update("balance", card.Balance).Error; err != nil {
+		return err
+	}
+	return tx.Create(&models.GiftCardTransaction{
+		GiftCardID:   card.ID,
+		Type:         txType,
+		Amount:       amount,
+		BalanceAfter: card.Balance,
+		OrderID:      orderID,
+		UserID:       userID,
+		ActorID:      actorID,
+	}).Error
+}
+
+// IssueGiftCard creates a card. The response is the only place the code
+// appears in clear.
+func IssueGiftCard(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var req struct {
+		Balance   models.Money `json:"balance" validate:"required,gt=0"`
+		Currency  string       `json:"currency"`
+		ExpiresAt *time.Time   `json:"expiresAt"`
+		Note      string       `json:"note" validate:"omitempty,max=255"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "expiresAt must be in the future"})
+		return
+	}
+	currency, err := normalizeCurrency(req.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+
+	code, err := generateGiftCardCode()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to generate gift card code"})
+		return
+	}
+
+	actorID := c.GetUint("user_id")
+	card := models.GiftCard{
+		CodeHash:       hashGiftCardCode(code),
+		Last4:          code[len(code)-4:],
+		InitialBalance: req.Balance,
+		Currency:       currency,
+		Active:         true,
+		ExpiresAt:      req.ExpiresAt,
+		IssuedBy:       actorID,
+		Note:           req.Note,
+	}
+	err = db.GormDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&card).Error; err != nil {
+			return err
+		}
+		return applyGiftCardChange(tx, &card, req.Balance, models.GiftCardTxIssue, 0, 0, actorID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to issue gift card"})
+		return
+	}
+
+	logger.AuditLog("gift_card_issued", actorID, c.ClientIP(), nil)
+	c.JSON(http.StatusCreated, models.APIResponse{Success: true, Message: "Gift card issued", Data: gin.H{"card": card, "code": code}})
+}
+
+func GetGiftCards(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	query := db.GormDB.Order("created_at DESC")
+	if raw := c.Query("active"); raw != "" {
+		active, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Invalid active filter"})
+			return
+		}
+		query = query.Where("active = ?", active)
+	}
+	var cards []models.GiftCard
+	if err := query.Find(&cards).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch gift cards"})
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: cards})
+}
+
+func GetGiftCard(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var card models.GiftCard
+	if err := db.GormDB.First(&card, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: "Gift card not found"})
+		return
+	}
+	var entries []models.GiftCardTransaction
+	if err := db.GormDB.Where("gift_card_id = ?", card.ID).Order("created_at DESC, id DESC").Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch gift card history"})
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: gin.H{"card": card, "transactions": entries}})
+}
+
+// DeactivateGiftCard stops a card from being used, e.g. when it was reported
+// lost. The balance is kept.
+func DeactivateGiftCard(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var card models.GiftCard
+	err := db.GormDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&card, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errGiftCardMissing
+			}
+			return err
+		}
+		card.Active = false
+		return tx.Model(&card).Update("active", false).Error
+	})
+	if err != nil {
+		writePurchaseError(c, err, "Failed to deactivate gift card")
+		return
+	}
+
+	logger.AuditLog("gift_card_deactivated", c.GetUint("user_id"), c.ClientIP(), nil)
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Gift card deactivated", Data: card})
+}
+
+// CheckGiftCard lets a customer see the balance of a code before using it.
+// The code is sent in the body so it does not end up in access logs.
+func CheckGiftCard(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var req struct {
+		Code string `json:"code" validate:"required,max=32"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return
+	}
+
+	var card models.GiftCard
+	if err := db.GormDB.First(&card, "code_hash = ?", hashGiftCardCode(req.Code)).Error; err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: "Gift card not found"})
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: gin.H{
+		"last4":     card.Last4,
+		"balance":   card.Balance,
+		"currency":  card.Currency,
+		"expiresAt": card.ExpiresAt,
+		"usable":    card.Usable(time.Now()),
+	}})
+}
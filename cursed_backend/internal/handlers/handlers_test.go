@@ -0,0 +1,7 @@
+package handlers
+
+import "os"
+
+// The package refuses to load without a JWT secret. Package variables are
+// initialized before init functions run, so tests get a throwaway one here.
+var _ = os.Setenv("JWT_SECRET", "handlers-test-secret")
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"cursed_backend/internal/db"
+	"cursed_backend/internal/logger"
+	"cursed_backend/internal/models"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errInsufficientPoints = &purchaseError{http.StatusPaymentRequired, "Not enough loyalty points"}
+
+// applyLoyaltyChange locks the user's row, moves the points balance and
+// appends the matching ledger entry, like applyWalletChange does for money.
+// Only reversals of earned points may take the balance below zero: points
+// earned on a refunded purchase are owed back even if already spent. Must
+// run inside a transaction.
+func applyLoyaltyChange(tx *gorm.DB, userID uint, points int64, txType string, orderID, actorID uint, note string) (*models.LoyaltyTransaction, error) {
+	var user models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errWalletNotFound
+		}
+		return nil, err
+	}
+
+	balance := user.LoyaltyPoints + points
+	if balance < 0 && points < 0 && txType != models.LoyaltyTxReversal {
+		return nil, errInsufficientPoints
+	}
+	if err := tx.Model(&user).Update("loyalty_points", balance).Error; err != nil {
+		return nil, err
+	}
+
+	entry := models.LoyaltyTransaction{
+		UserID:       userID,
+		Type:         txType,
+		Points:       points,
+		BalanceAfter: balance,
+		OrderID:      orderID,
+		ActorID:      actorID,
+		Note:         note,
+	}
+	if err := tx.Create(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// redeemLoyaltyPoints pays part of a freshly created order with points. The
+// request is capped at what covers the order total in whole points. Call it
+// between createOrder and chargeOrder.
+func redeemLoyaltyPoints(tx *gorm.DB, order *models.Order, points int64) error {
+	value := appConfig.LoyaltyPointValue
+	if points <= 0 || value <= 0 {
+		return nil
+	}
+	if limit := int64(order.Total) / value; points > limit {
+		points = limit
+	}
+	if points == 0 {
+		return nil
+	}
+
+	if _, err := applyLoyaltyChange(tx, order.UserID, -points, models.LoyaltyTxRedeem, order.ID, order.UserID, ""); err != nil {
+		return err
+	}
+	order.PointsRedeemed = points
+	order.PointsDiscount = models.Money(points * value)
+	order.Total -= order.PointsDiscount
+	return tx.Model(order).Updates(map[string]interface{}{
+		"total":           order.Total,
+		"points_redeemed": order.PointsRedeemed,
+		"points_discount": order.PointsDiscount,
+	}).Error
+}
+
+// awardLoyaltyPoints credits the points the charged order total earns.
+func awardLoyaltyPoints(tx *gorm.DB, order *models.Order) error {
+	points := int64(order.Total) * appConfig.LoyaltyPointsPerUnit / 100
+	if points <= 0 {
+		return nil
+	}
+	if _, err := applyLoyaltyChange(tx, order.UserID, points, models.LoyaltyTxEarn, order.ID, order.UserID, ""); err != nil {
+		return err
+	}
+	order.PointsEarned = points
+	return tx.Model(order).Update("points_earned", points).Error
+}
+
+func MyLoyalty(c *gin.Context) {
+	writeLoyalty(c, c.GetUint("user_id"))
+}
+
+func GetUserLoyalty(c *gin.Context) {
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Invalid user ID"})
+		return
+	}
+	writeLoyalty(c, uint(targetID))
+}
+
+func writeLoyalty(c *gin.Context, userID uint) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var user models.User
+	if err := db.GormDB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: "User not found"})
+		return
+	}
+
+	var entries []models.LoyaltyTransaction
+	if err := db.GormDB.Where("user_id = ?", userID).Order("created_at DESC, id DESC").Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch points history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: gin.H{
+		"points":       user.LoyaltyPoints,
+		"pointValue":   models.Money(appConfig.LoyaltyPointValue),
+		"transactions": entries,
+	}})
+}
+
+func AdjustLoyalty(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var req struct {
+		Points int64  `json:"points" validate:"required,ne=0"`
+		Note   string `json:"note" validate:"required,max=255"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return
+	}
+
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Invalid user ID"})
+		return
+	}
+
+	var entry *models.LoyaltyTransaction
+	err = db.GormDB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		entry, err = applyLoyaltyChange(tx, uint(targetID), req.Points, models.LoyaltyTxAdjustment, 0, c.GetUint("user_id"), req.Note)
+		return err
+	})
+	if err != nil {
+		logger.AuditLog("loyalty_adjustment", uint(targetID), c.ClientIP(), err)
+		writePurchaseError(c, err, "Points update failed")
+		return
+	}
+
+	logger.AuditLog("loyalty_adjustment", uint(targetID), c.ClientIP(), nil)
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Points updated", Data: entry})
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"cursed_backend/internal/db"
+	"cursed_backend/internal/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Related entity types recorded on stock movements
+const (
+	relatedOrder         = "order"
+	relatedReturn        = "return_request"
+	relatedPurchaseOrder = "purchase_order"
+)
+
+// recordStockMovement appends a ledger entry for a change of store stock. It
+// must run in the same transaction as the change itself.
+func recordStockMovement(tx *gorm.DB, productID, variantID uint, delta int, reason string, actorID uint, relatedType string, relatedID uint) error {
+	if delta == 0 {
+		return nil
+	}
+	return tx.Create(&models.StockMovement{
+		ProductID:   productID,
+		VariantID:   variantID,
+		Delta:       delta,
+		Reason:      reason,
+		ActorID:     actorID,
+		RelatedType: relatedType,
+		RelatedID:   relatedID,
+	}).Error
+}
+
+// storeStock is how much of a product counts as store inventory: all of it
+// for store listings, none for items owned by a customer.
+func storeStock(product *models.Product) int {
+	if product.OwnerID != 0 {
+		return 0
+	}
+	return product.Stock
+}
+
+func GetProductMovements(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var product models.Product
+	if err := db.GormDB.First(&product, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: "Product not found"})
+		return
+	}
+
+	var movements []models.StockMovement
+	if err := db.GormDB.Where("product_id = ?", product.ID).Order("id").Find(&movements).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch stock movements"})
+		return
+	}
+	total := 0
+	for _, m := range movements {
+		total += m.Delta
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: gin.H{
+		"movements":     movements,
+		"stock":         storeStock(&product),
+		"movementTotal": total,
+		"reconciled":    total == storeStock(&product),
+	}})
+}
+
+// GetStockReconciliation lists store products whose stock differs from the
+// sum of their movements.
+func GetStockReconciliation(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	type mismatch struct {
+		ProductID     uint   `json:"productId"`
+		Name          string `json:"name"`
+		Stock         int    `json:"stock"`
+		MovementTotal int    `json:"movementTotal"`
+	}
+	var mismatches []mismatch
+	if err := db.GormDB.Table("products p").
+		Select("p.id AS product_id, p.name, p.stock, COALESCE(SUM(m.delta), 0) AS movement_total").
+		Joins("LEFT JOIN stock_movements m ON m.product_id = p.id").
+		Where("p.owner_id = 0").
+		Group("p.id, p.name, p.stock").
+		Having("p.stock <> COALESCE(SUM(m.delta), 0)").
+		Order("p.id").
+		Scan(&mismatches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to reconcile stock"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: gin.H{"reconciled": len(mismatches) == 0, "mismatches": mismatches}})
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"cursed_backend/internal/db"
+	"cursed_backend/internal/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// createOrder writes the order and its items inside the caller's transaction,
+// after checking purchase limits over all its lines, and counts the
+// promotions they used. Subtotals and the order total are
+// computed here from the price snapshots. Store stock taken by product items
+// is recorded in the inventory ledger against the order.
+func createOrder(tx *gorm.DB, userID uint, items []models.OrderItem) (*models.Order, error) {
+	if err := enforcePurchaseLimits(tx, userID, items); err != nil {
+		return nil, err
+	}
+	if err := redeemPromotions(tx, items); err != nil {
+		return nil, err
+	}
+
+	order := models.Order{
+		UserID:   userID,
+		Status:   models.OrderStatusCompleted,
+		Currency: appConfig.Currency,
+		Items:    items,
+	}
+	for i := range order.Items {
+		order.Items[i].Subtotal = order.Items[i].UnitPrice.Times(order.Items[i].Quantity)
+		order.Total += order.Items[i].Subtotal
+	}
+	if err := tx.Create(&order).Error; err != nil {
+		return nil, err
+	}
+	for _, it := range order.Items {
+		if it.ItemType != models.ItemTypeProduct {
+			continue
+		}
+		if err := recordStockMovement(tx, it.ItemID, it.VariantID, -it.Quantity, models.StockReasonSale, userID, relatedOrder, order.ID); err != nil {
+			return nil, err
+		}
+	}
+	return &order, nil
+}
+
+func MyOrders(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var orders []models.Order
+	userID := c.GetUint("user_id")
+
+	if err := db.GormDB.Preload("Items").Where("user_id = ?", userID).Order("created_at DESC").Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch orders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: orders})
+}
+
+func GetOrders(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var orders []models.Order
+	query := db.GormDB.Preload("Items")
+
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		userID, err := strconv.ParseUint(userIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Invalid user ID"})
+			return
+		}
+		query = query.Where("user_id = ?", userID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Order("created_at DESC").Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch orders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: orders})
+}
@@ -4,14 +4,15 @@ import (
 	"cursed_backend/internal/db"
 	"cursed_backend/internal/models"
 	"errors"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 	"github.com/microcosm-cc/bluemonday"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 )
 
 func MyPets(c *gin.Context) {
@@ -73,14 +74,16 @@ func GetPets(c *gin.Context) {
 		}
 		query = query.Where("owner_id = ?", targetID)
 	} else {
+		// Store pets held by someone else are hidden until the hold ends
+		now := time.Now()
 		if isAuth {
 			if role == "manager" || role == "admin" {
 				// All pets
 			} else {
-				query = query.Where("owner_id = 0 OR owner_id = ?", userID)
+				query = query.Where("(owner_id = 0 AND (reserved_by = 0 OR reserved_by = ? OR reserved_until <= ?)) OR owner_id = ?", userID, now, userID)
 			}
 		} else {
-			query = query.Where("owner_id = 0")
+			query = query.Where("owner_id = 0 AND (reserved_by = 0 OR reserved_until <= ?)", now)
 		}
 	}
 
@@ -94,6 +97,22 @@ func GetPets(c *gin.Context) {
 		pets[i].Description = bluemonday.UGCPolicy().Sanitize(pets[i].Description)
 	}
 
+	if isAuth {
+		ids := make([]uint, len(pets))
+		for i := range pets {
+			ids[i] = pets[i].ID
+		}
+		favorites, err := favoriteSet(userID, models.ItemTypePet, ids)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch favorites"})
+			return
+		}
+		for i := range pets {
+			fav := favorites[pets[i].ID]
+			pets[i].IsFavorite = &fav
+		}
+	}
+
 	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: pets})
 }
 
@@ -143,6 +162,12 @@ func CreatePet(c *gin.Context) {
 	}
 
 	pet.Description = bluemonday.UGCPolicy().Sanitize(pet.Description)
+	currency, err := normalizeCurrency(pet.Currency)
+	if err != nil {
+		writePurchaseError(c, err, "Creation failed")
+		return
+	}
+	pet.Currency = currency
 
 	if pet.OwnerID > 0 {
 		var targetUser models.User
@@ -165,6 +190,26 @@ func BuyPet(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
 		return
 	}
+	if appConfig.AdoptionRequired {
+		writePurchaseError(c, errAdoptionRequired, "")
+		return
+	}
+
+	var req struct {
+		PromoCode    string `json:"promoCode" validate:"omitempty,max=50"`
+		RedeemPoints int64  `json:"redeemPoints" validate:"gte=0"`
+		GiftCardCode string `json:"giftCardCode" validate:"omitempty,max=32"`
+	}
+	// The body is optional
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return
+	}
 
 	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
 	userID := c.GetUint("user_id")
@@ -175,19 +220,40 @@ func BuyPet(c *gin.Context) {
 		}
 	}()
 
-	var pet models.Pet
-	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&pet, "id = ? AND owner_id = 0", id).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Pet not found or already owned"})
-		} else {
-			c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database error"})
-		}
+	book, err := newPriceBook(tx, req.PromoCode)
+	if err != nil {
+		writePurchaseError(c, err, "Failed to load promotions")
 		tx.Rollback()
 		return
 	}
 
-	if err := tx.Model(&pet).Update("owner_id", userID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Purchase failed"})
+	pet, item, err := purchasePet(tx, book, userID, uint(id))
+	if err != nil {
+		writePurchaseError(c, err, "Purchase failed")
+		tx.Rollback()
+		return
+	}
+
+	order, err := createOrder(tx, userID, []models.OrderItem{item})
+	if err != nil {
+		writePurchaseError(c, err, "Failed to record order")
+		tx.Rollback()
+		return
+	}
+
+	if err := redeemLoyaltyPoints(tx, order, req.RedeemPoints); err != nil {
+		writePurchaseError(c, err, "Failed to redeem points")
+		tx.Rollback()
+		return
+	}
+	if err := redeemGiftCard(tx, order, req.GiftCardCode); err != nil {
+		writePurchaseError(c, err, "Failed to redeem gift card")
+		tx.Rollback()
+		return
+	}
+
+	if err := chargeOrder(tx, order); err != nil {
+		writePurchaseError(c, err, "Payment failed")
 		tx.Rollback()
 		return
 	}
@@ -197,13 +263,13 @@ func BuyPet(c *gin.Context) {
 		return
 	}
 
-	if err := db.GormDB.First(&pet, id).Error; err != nil {
+	if err := db.GormDB.First(pet, id).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to refresh pet data"})
 		return
 	}
 
 	pet.Description = bluemonday.UGCPolicy().Sanitize(pet.Description)
-	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Pet purchased", Data: pet})
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Pet purchased", Data: pet, Order: order})
 }
 
 func UpdatePet(c *gin.Context) {
@@ -235,6 +301,14 @@ func UpdatePet(c *gin.Context) {
 	}
 	input.ID = 0
 	input.Description = bluemonday.UGCPolicy().Sanitize(input.Description)
+	if input.Currency != "" {
+		currency, err := normalizeCurrency(input.Currency)
+		if err != nil {
+			writePurchaseError(c, err, "Update failed")
+			return
+		}
+		input.Currency = currency
+	}
 	if input.OwnerID != pet.OwnerID && input.OwnerID > 0 {
 		var targetUser models.User
 		if err := db.GormDB.First(&targetUser, input.OwnerID).Error; err != nil {
@@ -242,7 +316,16 @@ func UpdatePet(c *gin.Context) {
 			return
 		}
 	}
-	if err := db.GormDB.Model(&pet).Updates(&input).Error; err != nil {
+	err := db.GormDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&pet).Updates(&input).Error; err != nil {
+			return err
+		}
+		if input.Price == 0 || pet.OwnerID != 0 {
+			return nil
+		}
+		return recordPriceChange(tx, models.ItemTypePet, pet.ID, 0, pet.Price, input.Price, userID)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Update failed"})
 		return
 	}
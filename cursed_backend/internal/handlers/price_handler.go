@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"context"
+	"cursed_backend/internal/db"
+	"cursed_backend/internal/jobs"
+	"cursed_backend/internal/logger"
+	"cursed_backend/internal/models"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const priceChangeBatchSize = 100
+
+// recordPriceChange appends an already applied entry to an item's price
+// history. variantID is 0 unless the price is a product variant's. Call it in
+// the transaction that changes the price.
+func recordPriceChange(tx *gorm.DB, itemType string, itemID, variantID uint, oldPrice, newPrice models.Money, actorID uint) error {
+	if oldPrice == newPrice {
+		return nil
+	}
+	now := time.Now()
+	return tx.Create(&models.PriceChange{
+		ItemType:    itemType,
+		ItemID:      itemID,
+		VariantID:   variantID,
+		OldPrice:    oldPrice,
+		NewPrice:    newPrice,
+		Status:      models.PriceChangeApplied,
+		EffectiveAt: now,
+		AppliedAt:   &now,
+		ActorID:     actorID,
+	}).Error
+}
+
+// itemExists reports whether the store pet or product behind a price change
+// exists, and with a variantID whether the product has that variant.
+// Customers' owned copies keep the price they paid and have no price history.
+func itemExists(tx *gorm.DB, itemType string, itemID, variantID uint) (bool, error) {
+	var count int64
+	var err error
+	switch {
+	case itemType == models.ItemTypePet:
+		err = tx.Model(&models.Pet{}).Where("id = ? AND owner_id = 0", itemID).Count(&count).Error
+	case variantID != 0:
+		err = tx.Model(&models.ProductVariant{}).
+			Joins("JOIN products ON products.id = product_variants.product_id AND products.owner_id = 0").
+			Where("product_variants.id = ? AND product_variants.product_id = ?", variantID, itemID).Count(&count).Error
+	default:
+		err = tx.Model(&models.Product{}).Where("id = ? AND owner_id = 0", itemID).Count(&count).Error
+	}
+	return count > 0, err
+}
+
+// discountedPrice takes percentOff off price, never going below one cent.
+func discountedPrice(price models.Money, percentOff float64) models.Money {
+	discounted := price - models.Money(math.Round(float64(price)*percentOff/100))
+	if discounted < 1 {
+		return 1
+	}
+	return discounted
+}
+
+func GetPetPriceHistory(c *gin.Context) {
+	priceHistory(c, models.ItemTypePet)
+}
+
+func GetProductPriceHistory(c *gin.Context) {
+	priceHistory(c, models.ItemTypeProduct)
+}
+
+// priceHistory lists every recorded, scheduled and cancelled price change of
+// the item, newest first. A product's history includes its variants'.
+func priceHistory(c *gin.Context, itemType string) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var changes []models.PriceChange
+	if err := db.GormDB.Where("item_type = ? AND item_id = ?", itemType, id).
+		Order("effective_at DESC, id DESC").Find(&changes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch price history"})
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: changes})
+}
+
+func SchedulePetPriceChange(c *gin.Context) {
+	schedulePriceChange(c, models.ItemTypePet)
+}
+
+func ScheduleProductPriceChange(c *gin.Context) {
+	schedulePriceChange(c, models.ItemTypeProduct)
+}
+
+// schedulePriceChange queues a price change of a store pet or product, or of
+// one variant of a product when variantId is given.
+func schedulePriceChange(c *gin.Context, itemType string) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var req struct {
+		Price       models.Money `json:"price" validate:"required_without=PercentOff,excluded_with=PercentOff,omitempty,gt=0"`
+		PercentOff  float64      `json:"percentOff" validate:"required_without=Price,omitempty,gt=0,lt=100"`
+		VariantID   uint         `json:"variantId"`
+		EffectiveAt time.Time    `json:"effectiveAt" validate:"required"`
+		Note        string       `json:"note" validate:"omitempty,max=255"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return
+	}
+	if !req.EffectiveAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "effectiveAt must be in the future"})
+		return
+	}
+
+	if req.VariantID != 0 && itemType != models.ItemTypeProduct {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Only products have variants"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	exists, err := itemExists(db.GormDB, itemType, uint(id), req.VariantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to schedule price change"})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: "Item not found"})
+		return
+	}
+
+	change := models.PriceChange{
+		ItemType:    itemType,
+		ItemID:      uint(id),
+		VariantID:   req.VariantID,
+		NewPrice:    req.Price,
+		PercentOff:  req.PercentOff,
+		Status:      models.PriceChangeScheduled,
+		EffectiveAt: req.EffectiveAt,
+		ActorID:     c.GetUint("user_id"),
+		Note:        req.Note,
+	}
+	if err := db.GormDB.Create(&change).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to schedule price change"})
+		return
+	}
+
+	logger.AuditLog("price_change_scheduled", change.ActorID, c.ClientIP(), nil)
+	c.JSON(http.StatusCreated, models.APIResponse{Success: true, Message: "Price change scheduled", Data: change})
+}
+
+// GetPriceChanges lists price changes across items, filtered by ?status and
+// ?item_type.
+func GetPriceChanges(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	query := db.GormDB.Order("effective_at DESC, id DESC")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if itemType := c.Query("item_type"); itemType != "" {
+		query = query.Where("item_type = ?", itemType)
+	}
+	var changes []models.PriceChange
+	if err := query.Find(&changes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch price changes"})
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: changes})
+}
+
+func CancelPriceChange(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	result := db.GormDB.Model(&models.PriceChange{}).
+		Where("id = ? AND status = ?", id, models.PriceChangeScheduled).
+		Update("status", models.PriceChangeCancelled)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to cancel price change"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, models.APIResponse{Success: false, Message: "Price change not found or no longer scheduled"})
+		return
+	}
+
+	logger.AuditLog("price_change_cancelled", c.GetUint("user_id"), c.ClientIP(), nil)
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Price change cancelled"})
+}
+
+// ApplyDuePriceChanges applies scheduled price changes whose effective time
+// has come, oldest first, each in its own transaction.
+func ApplyDuePriceChanges() error {
+	if db.GormDB == nil {
+		return nil
+	}
+
+	now := time.Now()
+	var ids []uint
+	if err := db.GormDB.Model(&models.PriceChange{}).
+		Where("status = ? AND effective_at <= ?", models.PriceChangeScheduled, now).
+		Order("effective_at, id").Limit(priceChangeBatchSize).
+		Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+
+	var failed int
+	for _, id := range ids {
+		if err := applyPriceChange(id, now); err != nil {
+			failed++
+			logger.Log.WithError(err).WithField("price_change_id", id).Error("Failed to apply price change")
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d price changes failed", failed)
+	}
+	return nil
+}
+
+// applyPriceChange locks the change and its item or variant and sets the new
+// price. A change whose item or variant has been deleted, or whose item is no
+// longer a store item, is cancelled.
+func applyPriceChange(id uint, now time.Time) error {
+	return db.GormDB.Transaction(func(tx *gorm.DB) error {
+		var change models.PriceChange
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			First(&change, "id = ? AND status = ? AND effective_at <= ?", id, models.PriceChangeScheduled, now).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		var target *gorm.DB
+		switch {
+		case change.ItemType == models.ItemTypePet:
+			target = tx.Model(&models.Pet{}).Where("id = ? AND owner_id = 0", change.ItemID)
+		case change.VariantID != 0:
+			target = tx.Model(&models.ProductVariant{}).Where("id = ? AND product_id = ? AND product_id IN (?)",
+				change.VariantID, change.ItemID, tx.Model(&models.Product{}).Select("id").Where("owner_id = 0"))
+		default:
+			target = tx.Model(&models.Product{}).Where("id = ? AND owner_id = 0", change.ItemID)
+		}
+		var current models.Money
+		err := target.Session(&gorm.Session{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("price").Take(&current).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			change.Status = models.PriceChangeCancelled
+			return tx.Save(&change).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		price := change.NewPrice
+		if change.PercentOff > 0 {
+			price = discountedPrice(current, change.PercentOff)
+		}
+		if err := target.Session(&gorm.Session{}).Update("price", price).Error; err != nil {
+			return err
+		}
+
+		change.OldPrice = current
+		change.NewPrice = price
+		change.Status = models.PriceChangeApplied
+		change.AppliedAt = &now
+		if err := tx.Save(&change).Error; err != nil {
+			return err
+		}
+		logger.Log.WithFields(map[string]interface{}{
+			"price_change_id": change.ID,
+			"item_type":       change.ItemType,
+			"item_id":         change.ItemID,
+			"variant_id":      change.VariantID,
+			"old_price":       current.String(),
+			"new_price":       price.String(),
+		}).Info("Scheduled price change applied")
+		return nil
+	})
+}
+
+// StartPriceChangeScheduler applies due price changes every interval until ctx is done.
+func StartPriceChangeScheduler(ctx context.Context, interval time.Duration) {
+	jobs.RunEvery(ctx, "price_change_scheduler", interval, ApplyDuePriceChanges)
+}
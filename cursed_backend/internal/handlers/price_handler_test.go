@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"cursed_backend/internal/models"
+	"testing"
+)
+
+func TestDiscountedPrice(t *testing.T) {
+	tests := []struct {
+		name       string
+		price      models.Money
+		percentOff float64
+		want       models.Money
+	}{
+		{"quarter off", 2000, 25, 1500},
+		{"rounds to the cent", 999, 10, 899},
+		{"never below a cent", 1, 99, 1},
+		{"deep cut on a cheap item", 50, 99.5, 1},
+		{"tiny cut", 10000, 0.01, 9999},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := discountedPrice(tt.price, tt.percentOff); got != tt.want {
+				t.Errorf("discountedPrice(%d, %v) = %d, want %d", tt.price, tt.percentOff, got, tt.want)
+			}
+		})
+	}
+}
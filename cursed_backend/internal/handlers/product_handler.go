@@ -7,15 +7,18 @@ import (
 	"cursed_backend/internal/logger"
 	"cursed_backend/internal/models"
 	"errors"
+	"io"
 	"net/http"
+	"sort"
 	"strconv"
 	"time"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/sirupsen/logrus"
-	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func MyProducts(c *gin.Context) {
@@ -44,6 +47,13 @@ func MyProducts(c *gin.Context) {
 	})
 }
 
+// hideStaffFields clears what only managers may see of a store product, such
+// as the cost price from purchase orders. Every product handed to customers
+// goes through it.
+func hideStaffFields(product *models.Product) {
+	product.CostPrice = 0
+}
+
 func GetProducts(c *gin.Context) {
 	if db.GormDB == nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -53,6 +63,15 @@ func GetProducts(c *gin.Context) {
 		return
 	}
 
+	sortBy := c.Query("sort")
+	if sortBy != "" && sortBy != sortByRating {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Unsupported sort: " + sortBy,
+		})
+		return
+	}
+
 	var products []models.Product
 	query := db.GormDB
 
@@ -105,13 +124,58 @@ func GetProducts(c *gin.Context) {
 		}
 	}
 
-	if err := query.Find(&products).Error; err != nil {
+	if err := query.Scopes(preloadVariants).Find(&products).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
 			Message: "Failed to fetch products: " + err.Error(),
 		})
 		return
 	}
+	if role != "manager" && role != "admin" {
+		for i := range products {
+			hideStaffFields(&products[i])
+		}
+	}
+	if err := attachRatings(db.GormDB, products); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch ratings",
+		})
+		return
+	}
+	if err := attachFlashSales(db.GormDB, products, time.Now()); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to fetch flash sales",
+		})
+		return
+	}
+	if sortBy == sortByRating {
+		sort.SliceStable(products, func(a, b int) bool {
+			if products[a].AverageRating != products[b].AverageRating {
+				return products[a].AverageRating > products[b].AverageRating
+			}
+			return products[a].ReviewCount > products[b].ReviewCount
+		})
+	}
+	if isAuth {
+		ids := make([]uint, len(products))
+		for i := range products {
+			ids[i] = products[i].ID
+		}
+		favorites, err := favoriteSet(userID, models.ItemTypeProduct, ids)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to fetch favorites",
+			})
+			return
+		}
+		for i := range products {
+			fav := favorites[products[i].ID]
+			products[i].IsFavorite = &fav
+		}
+	}
 
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
@@ -130,7 +194,7 @@ func GetProduct(c *gin.Context) {
 
 	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
 	var product models.Product
-	if err := db.GormDB.First(&product, id).Error; err != nil {
+	if err := db.GormDB.Scopes(preloadVariants).First(&product, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, models.APIResponse{
 			Success: false,
 			Message: "Product not found",
@@ -178,6 +242,22 @@ func CreateProduct(c *gin.Context) {
 		return
 	}
 
+	if len(product.Variants) > 0 {
+		// Products with variants hold the sum of their variants' stock
+		product.Stock = 0
+		for i := range product.Variants {
+			product.Variants[i].ID = 0
+			if err := models.ValidateProductVariant(&product.Variants[i]); err != nil {
+				c.JSON(http.StatusBadRequest, models.APIResponse{
+					Success: false,
+					Message: "Validation failed: " + err.Error(),
+				})
+				return
+			}
+			product.Stock += product.Variants[i].Stock
+		}
+	}
+
 	err := models.ValidateProduct(&product)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
@@ -186,6 +266,11 @@ func CreateProduct(c *gin.Context) {
 		})
 		return
 	}
+	product.Currency, err = normalizeCurrency(product.Currency)
+	if err != nil {
+		writePurchaseError(c, err, "Creation failed")
+		return
+	}
 	if product.OwnerID > 0 {
 		var targetUser models.User
 		if err := db.GormDB.First(&targetUser, product.OwnerID).Error; err != nil {
@@ -198,7 +283,21 @@ func CreateProduct(c *gin.Context) {
 	}
 
 	product.CreatedAt = time.Now()
-	if err := db.GormDB.Create(&product).Error; err != nil {
+	err = db.GormDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+		if product.OwnerID != 0 || len(product.Variants) == 0 {
+			return recordStockMovement(tx, product.ID, 0, storeStock(&product), models.StockReasonInitial, c.GetUint("user_id"), "", 0)
+		}
+		for _, v := range product.Variants {
+			if err := recordStockMovement(tx, product.ID, v.ID, v.Stock, models.StockReasonInitial, c.GetUint("user_id"), "", 0); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
 			Message: err.Error(),
@@ -220,9 +319,36 @@ func BuyProduct(c *gin.Context) {
 		return
 	}
 
+	var req struct {
+		VariantID    uint   `json:"variantId"`
+		Quantity     int    `json:"quantity" validate:"omitempty,min=1"`
+		PromoCode    string `json:"promoCode" validate:"omitempty,max=50"`
+		RedeemPoints int64  `json:"redeemPoints" validate:"gte=0"`
+		GiftCardCode string `json:"giftCardCode" validate:"omitempty,max=32"`
+	}
+	// The body is optional; an empty one buys a single unit
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Validation failed: " + err.Error(),
+		})
+		return
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
 	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
 	userID := c.GetUint("user_id")
-	logger.Log.WithFields(logrus.Fields{"user_id": userID, "action": "buy_product"}).Info("BuyProduct called") // Fixed log
+	logger.Log.WithFields(logrus.Fields{"user_id": userID, "action": "buy_product", "quantity": req.Quantity}).Info("BuyProduct called") // Fixed log
 	tx := db.GormDB.Begin()
 	defer func() {
 		if r := recover(); r != nil {
@@ -230,50 +356,46 @@ func BuyProduct(c *gin.Context) {
 		}
 	}()
 
-	var storeProduct models.Product
-	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&storeProduct, "id = ? AND owner_id = 0 AND stock > 0", id).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusBadRequest, models.APIResponse{
-				Success: false,
-				Message: "Product not found, not available, or out of stock",
-			})
-		} else {
-			c.JSON(http.StatusInternalServerError, models.APIResponse{
-				Success: false,
-				Message: "Database error: " + err.Error(),
-			})
-		}
+	book, err := newPriceBook(tx, req.PromoCode)
+	if err != nil {
+		writePurchaseError(c, err, "Failed to load promotions: "+err.Error())
 		tx.Rollback()
 		return
 	}
 
-	result := tx.Model(&storeProduct).Update("stock", gorm.Expr("stock - ?", 1))
-	if result.Error != nil || result.RowsAffected == 0 {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
-			Success: false,
-			Message: "Stock update failed: " + result.Error.Error(),
-		})
+	var hooks afterCommit
+	ownedProduct, item, err := purchaseProduct(tx, book, &hooks, userID, uint(id), req.VariantID, req.Quantity)
+	if err != nil {
+		writePurchaseError(c, err, "Purchase failed: "+err.Error())
+		tx.Rollback()
+		return
+	}
+	if err := applyFlashSale(tx, book, userID, &item); err != nil {
+		writePurchaseError(c, err, "Purchase failed: "+err.Error())
 		tx.Rollback()
 		return
 	}
 
-	ownedProduct := models.Product{
-		Name:        storeProduct.Name,
-		Description: storeProduct.Description,
-		Price:       storeProduct.Price,
-		Stock:       1,
-		Category:    storeProduct.Category,
-		Brand:       storeProduct.Brand,
-		Image:       storeProduct.Image,
-		Mass:        storeProduct.Mass,
-		OwnerID:     userID,
-		CreatedAt:   time.Now(),
+	order, err := createOrder(tx, userID, []models.OrderItem{item})
+	if err != nil {
+		writePurchaseError(c, err, "Failed to record order: "+err.Error())
+		tx.Rollback()
+		return
 	}
-	if err := tx.Create(&ownedProduct).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
-			Success: false,
-			Message: "Purchase failed: " + err.Error(),
-		})
+
+	if err := redeemLoyaltyPoints(tx, order, req.RedeemPoints); err != nil {
+		writePurchaseError(c, err, "Failed to redeem points: "+err.Error())
+		tx.Rollback()
+		return
+	}
+	if err := redeemGiftCard(tx, order, req.GiftCardCode); err != nil {
+		writePurchaseError(c, err, "Failed to redeem gift card: "+err.Error())
+		tx.Rollback()
+		return
+	}
+
+	if err := chargeOrder(tx, order); err != nil {
+		writePurchaseError(c, err, "Payment failed: "+err.Error())
 		tx.Rollback()
 		return
 	}
@@ -285,11 +407,13 @@ func BuyProduct(c *gin.Context) {
 		})
 		return
 	}
+	hooks.run()
 
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "Product purchased",
 		Data:    ownedProduct,
+		Order:   order,
 	})
 }
 
@@ -336,6 +460,16 @@ func UpdateProduct(c *gin.Context) {
 		return
 	}
 	input.ID = 0
+	// Variants have their own endpoints
+	input.Variants = nil
+	if input.Currency != "" {
+		currency, err := normalizeCurrency(input.Currency)
+		if err != nil {
+			writePurchaseError(c, err, "Update failed")
+			return
+		}
+		input.Currency = currency
+	}
 	if input.OwnerID != product.OwnerID && input.OwnerID > 0 {
 		var targetUser models.User
 		if err := db.GormDB.First(&targetUser, input.OwnerID).Error; err != nil {
@@ -346,20 +480,42 @@ func UpdateProduct(c *gin.Context) {
 			return
 		}
 	}
-	if err := db.GormDB.Model(&product).Updates(&input).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
-			Success: false,
-			Message: err.Error(),
-		})
-		return
-	}
-	if err := db.GormDB.First(&product, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
-			Success: false,
-			Message: "Failed to refresh product data: " + err.Error(),
-		})
+	var previousStock int
+	err := db.GormDB.Transaction(func(tx *gorm.DB) error {
+		// Lock the row so the stock delta is measured against what purchases see
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, id).Error; err != nil {
+			return err
+		}
+		previousStock = product.Stock
+		previousPrice := product.Price
+		before := storeStock(&product)
+		if input.Stock != 0 && input.Stock != product.Stock {
+			var variants int64
+			if err := tx.Model(&models.ProductVariant{}).Where("product_id = ?", product.ID).Count(&variants).Error; err != nil {
+				return err
+			}
+			if variants > 0 {
+				return errVariantStockManaged
+			}
+		}
+		if err := tx.Model(&product).Updates(&input).Error; err != nil {
+			return err
+		}
+		if err := tx.Scopes(preloadVariants).First(&product, id).Error; err != nil {
+			return err
+		}
+		if product.OwnerID == 0 {
+			if err := recordPriceChange(tx, models.ItemTypeProduct, product.ID, 0, previousPrice, product.Price, userID); err != nil {
+				return err
+			}
+		}
+		return recordStockMovement(tx, product.ID, 0, storeStock(&product)-before, models.StockReasonAdjustment, userID, "", 0)
+	})
+	if err != nil {
+		writePurchaseError(c, err, "Update failed: "+err.Error())
 		return
 	}
+	afterRestock(&product, previousStock)
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Data:    product,
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"cursed_backend/internal/db"
+	"cursed_backend/internal/models"
+	"cursed_backend/internal/pricing"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetPromotions(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var promos []models.Promotion
+	query := db.GormDB
+	if active := c.Query("active"); active != "" {
+		query = query.Where("active = ?", active == "true")
+	}
+
+	if err := query.Order("id").Find(&promos).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch promotions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: promos})
+}
+
+func CreatePromotion(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	promo := models.Promotion{Active: true}
+	if err := c.ShouldBindJSON(&promo); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	promo.ID = 0
+	promo.UsedCount = 0
+	promo.Code = pricing.NormalizeCode(promo.Code)
+	if err := models.ValidatePromotion(&promo); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return
+	}
+
+	if err := db.GormDB.Create(&promo).Error; err != nil {
+		c.JSON(http.StatusConflict, models.APIResponse{Success: false, Message: "Promotion code already exists"})
+		return
+	}
+	c.JSON(http.StatusCreated, models.APIResponse{Success: true, Data: promo})
+}
+
+func UpdatePromotion(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var promo models.Promotion
+	if err := db.GormDB.First(&promo, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: "Promotion not found"})
+		return
+	}
+
+	// Bind over the stored row so omitted fields keep their values and
+	// booleans like active can be switched off
+	usedCount := promo.UsedCount
+	if err := c.ShouldBindJSON(&promo); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	promo.ID = uint(id)
+	promo.UsedCount = usedCount
+	promo.Code = pricing.NormalizeCode(promo.Code)
+	if err := models.ValidatePromotion(&promo); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return
+	}
+
+	if err := db.GormDB.Omit("used_count", "created_at").Save(&promo).Error; err != nil {
+		c.JSON(http.StatusConflict, models.APIResponse{Success: false, Message: "Update failed"})
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: promo})
+}
+
+func DeletePromotion(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	result := db.GormDB.Delete(&models.Promotion{}, id)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Delete failed"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: "Promotion not found"})
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Promotion deleted"})
+}
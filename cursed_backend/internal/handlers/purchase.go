@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"cursed_backend/internal/models"
+	"cursed_backend/internal/pricing"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// purchaseError is a business-rule failure during a purchase. Its message is
+// safe to return to the client, unlike raw database errors.
+type purchaseError struct {
+	status  int
+	message string
+}
+
+func (e *purchaseError) Error() string { return e.message }
+
+var (
+	errPetUnavailable     = &purchaseError{http.StatusBadRequest, "Pet not found or already owned"}
+	errProductUnavailable = &purchaseError{http.StatusBadRequest, "Product not found, not available, or out of stock"}
+	errInsufficientStock  = &purchaseError{http.StatusBadRequest, "Not enough stock"}
+	errInvalidQuantity    = &purchaseError{http.StatusBadRequest, "Quantity must be at least 1"}
+	errPurchaseLimit      = &purchaseError{http.StatusBadRequest, "Purchase limit for this product exceeded"}
+	errInvalidPromoCode   = &purchaseError{http.StatusBadRequest, "Invalid or expired promo code"}
+	errPromotionExhausted = &purchaseError{http.StatusConflict, "Promotion is no longer available"}
+	errCurrencyMismatch   = &purchaseError{http.StatusConflict, "Item is priced in a different currency"}
+	errVariantRequired    = &purchaseError{http.StatusBadRequest, "Choose a variant of this product"}
+	errVariantUnavailable = &purchaseError{http.StatusBadRequest, "Variant not found for this product"}
+)
+
+// priceBook prices the items of one purchase against the promotions that
+// were active when it started.
+type priceBook struct {
+	promos []models.Promotion
+	code   string
+	now    time.Time
+}
+
+// newPriceBook loads the active promotions. A non-empty code must belong to
+// one of them, otherwise errInvalidPromoCode is returned.
+func newPriceBook(tx *gorm.DB, code string) (*priceBook, error) {
+	book := &priceBook{code: pricing.NormalizeCode(code), now: time.Now()}
+	if err := tx.Where("active = ? AND (starts_at IS NULL OR starts_at <= ?) AND (ends_at IS NULL OR ends_at > ?)", true, book.now, book.now).
+		Order("id").Find(&book.promos).Error; err != nil {
+		return nil, err
+	}
+	if book.code == "" {
+		return book, nil
+	}
+	for _, p := range book.promos {
+		if pricing.NormalizeCode(p.Code) == book.code && pricing.Active(p, book.now) {
+			return book, nil
+		}
+	}
+	return nil, errInvalidPromoCode
+}
+
+func (b *priceBook) quote(item pricing.Item) pricing.Quote {
+	return pricing.Apply(item, b.promos, b.code, b.now)
+}
+
+// afterCommit collects side effects of a purchase, such as stock alerts,
+// that must only happen once its transaction has committed.
+type afterCommit []func()
+
+func (a *afterCommit) add(f func()) {
+	*a = append(*a, f)
+}
+
+// run runs the queued side effects. Call it after the transaction commits;
+// a rolled back purchase just drops the collector.
+func (a *afterCommit) run() {
+	for _, f := range *a {
+		f()
+	}
+	*a = nil
+}
+
+// redeemPromotions counts one use per promotion applied in the order. The
+// conditional update keeps concurrent orders from going over a usage cap.
+func redeemPromotions(tx *gorm.DB, items []models.OrderItem) error {
+	used := map[uint]bool{}
+	for _, it := range items {
+		if it.PromotionID == 0 || used[it.PromotionID] {
+			continue
+		}
+		used[it.PromotionID] = true
+		result := tx.Model(&models.Promotion{}).
+			Where("id = ? AND (usage_limit = 0 OR used_count < usage_limit)", it.PromotionID).
+			Update("used_count", gorm.Expr("used_count + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errPromotionExhausted
+		}
+	}
+	return nil
+}
+
+// writePurchaseError answers with the purchaseError's status and message, or
+// with a 500 and the given fallback message for anything else.
+func writePurchaseError(c *gin.Context, err error, fallback string) {
+	var pe *purchaseError
+	if errors.As(err, &pe) {
+		c.JSON(pe.status, models.APIResponse{Success: false, Message: pe.message})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: fallback})
+}
+
+// isUniqueViolation reports whether err is Postgres rejecting a duplicate key
+// (SQLSTATE 23505).
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// purchasePet locks a store pet and hands it to userID, unless someone else
+// holds a reservation on it. It must run inside a transaction; the returned
+// order item is not yet persisted.
+func purchasePet(tx *gorm.DB, book *priceBook, userID, petID uint) (*models.Pet, models.OrderItem, error) {
+	var pet models.Pet
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&pet, "id = ? AND owner_id = 0", petID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.OrderItem{}, errPetUnavailable
+		}
+		return nil, models.OrderItem{}, err
+	}
+
+	if pet.HeldByOther(userID, time.Now()) {
+		return nil, models.OrderItem{}, errPetReserved
+	}
+	if !storeCurrency(pet.Currency) {
+		return nil, models.OrderItem{}, errCurrencyMismatch
+	}
+
+	if err := tx.Model(&pet).Updates(map[string]interface{}{"owner_id": userID, "reserved_by": 0, "reserved_until": nil}).Error; err != nil {
+		return nil, models.OrderItem{}, err
+	}
+
+	quote := book.quote(pricing.Item{Type: models.ItemTypePet, Price: pet.Price, Breed: pet.Breed})
+	item := models.OrderItem{
+		ItemType:    models.ItemTypePet,
+		ItemID:      pet.ID,
+		OwnedItemID: pet.ID,
+		Name:        pet.Name,
+		ListPrice:   quote.ListPrice,
+		Discount:    quote.Discount,
+		PromotionID: quote.PromotionID,
+		UnitPrice:   quote.FinalPrice,
+		Quantity:    1,
+	}
+	return &pet, item, nil
+}
+
+// purchaseProduct locks a store product, takes quantity units off its stock
+// and creates the owned copy for userID. It must run inside a transaction;
+// the returned order item is not yet persisted, and createOrder checks the
+// purchase limit and records the matching stock movement once it is. Products with variants are bought by
+// variant: variantID is then required and its price, mass and stock apply.
+func purchaseProduct(tx *gorm.DB, book *priceBook, hooks *afterCommit, userID, productID, variantID uint, quantity int) (*models.Product, models.OrderItem, error) {
+	if quantity < 1 {
+		return nil, models.OrderItem{}, errInvalidQuantity
+	}
+
+	var storeProduct models.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&storeProduct, "id = ? AND owner_id = 0 AND stock > 0", productID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.OrderItem{}, errProductUnavailable
+		}
+		return nil, models.OrderItem{}, err
+	}
+	variant, err := lockVariant(tx, &storeProduct, variantID)
+	if err != nil {
+		return nil, models.OrderItem{}, err
+	}
+	name, price, mass, available := storeProduct.Name, storeProduct.Price, storeProduct.Mass, storeProduct.Stock
+	if variant != nil {
+		name = storeProduct.Name + " (" + variant.Name + ")"
+		price, mass, available = variant.Price, variant.Mass, variant.Stock
+	}
+	if available < quantity {
+		return nil, models.OrderItem{}, errInsufficientStock
+	}
+	if !storeCurrency(storeProduct.Currency) {
+		return nil, models.OrderItem{}, errCurrencyMismatch
+	}
+	result := tx.Model(&storeProduct).Update("stock", gorm.Expr("stock - ?", quantity))
+	if result.Error != nil {
+		return nil, models.OrderItem{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, models.OrderItem{}, errProductUnavailable
+	}
+	if variant != nil {
+		if err := tx.Model(variant).Update("stock", gorm.Expr("stock - ?", quantity)).Error; err != nil {
+			return nil, models.OrderItem{}, err
+		}
+	}
+	if crossesReorderThreshold(&storeProduct, quantity) {
+		alerted := storeProduct
+		alerted.Stock -= quantity
+		hooks.add(func() { emitLowStockAlert(&alerted) })
+	}
+
+	ownedProduct := models.Product{
+		Name:        name,
+		Description: storeProduct.Description,
+		Price:       price,
+		Currency:    storeProduct.Currency,
+		Stock:       quantity,
+		Category:    storeProduct.Category,
+		Brand:       storeProduct.Brand,
+		Image:       storeProduct.Image,
+		Mass:        mass,
+		OwnerID:     userID,
+		SourceID:    storeProduct.ID,
+		CreatedAt:   time.Now(),
+	}
+	if err := tx.Create(&ownedProduct).Error; err != nil {
+		return nil, models.OrderItem{}, err
+	}
+
+	quote := book.quote(pricing.Item{
+		Type:     models.ItemTypeProduct,
+		Price:    price,
+		Category: storeProduct.Category,
+		Brand:    storeProduct.Brand,
+	})
+	item := models.OrderItem{
+		ItemType:    models.ItemTypeProduct,
+		ItemID:      storeProduct.ID,
+		VariantID:   variantID,
+		OwnedItemID: ownedProduct.ID,
+		Name:        name,
+		ListPrice:   quote.ListPrice,
+		Discount:    quote.Discount,
+		PromotionID: quote.PromotionID,
+		UnitPrice:   quote.FinalPrice,
+		Quantity:    quantity,
+	}
+	return &ownedProduct, item, nil
+}
+
+// lockVariant locks the variant of a locked store product that is being
+// bought. It returns nil for products without variants, which must then be
+// bought with variantID 0.
+func lockVariant(tx *gorm.DB, product *models.Product, variantID uint) (*models.ProductVariant, error) {
+	return findVariant(tx, product, variantID, true)
+}
+
+// findVariant is lockVariant for reads that only check availability.
+func findVariant(tx *gorm.DB, product *models.Product, variantID uint, lock bool) (*models.ProductVariant, error) {
+	if variantID == 0 {
+		var count int64
+		if err := tx.Model(&models.ProductVariant{}).Where("product_id = ?", product.ID).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, errVariantRequired
+		}
+		return nil, nil
+	}
+
+	query := tx
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	var variant models.ProductVariant
+	if err := query.First(&variant, "id = ? AND product_id = ?", variantID, product.ID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errVariantUnavailable
+		}
+		return nil, err
+	}
+	return &variant, nil
+}
+
+// purchasedQuantity is how many units of a store product the user has bought
+// and not returned.
+func purchasedQuantity(tx *gorm.DB, userID, productID uint) (int, error) {
+	var bought int64
+	err := tx.Model(&models.OrderItem{}).
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("orders.user_id = ? AND order_items.item_type = ? AND order_items.item_id = ?", userID, models.ItemTypeProduct, productID).
+		Select("COALESCE(SUM(order_items.quantity - order_items.returned), 0)").
+		Scan(&bought).Error
+	return int(bought), err
+}
+
+// checkPurchaseLimits checks every limited product against what the customer
+// already bought plus all lines of the new order, so several variants of one
+// product, or bundle components, cannot each use up the whole limit. limits
+// only holds limited products; bought is keyed by the same product IDs.
+func checkPurchaseLimits(items []models.OrderItem, limits, bought map[uint]int) error {
+	ordered := map[uint]int{}
+	for _, it := range items {
+		if it.ItemType == models.ItemTypeProduct {
+			ordered[it.ItemID] += it.Quantity
+		}
+	}
+	for id, limit := range limits {
+		if bought[id]+ordered[id] > limit {
+			return errPurchaseLimit
+		}
+	}
+	return nil
+}
+
+// enforcePurchaseLimits loads the limits and earlier purchases of the
+// products in items for checkPurchaseLimits. purchaseProduct has locked the
+// store rows, so concurrent buys of a limited product are serialized.
+func enforcePurchaseLimits(tx *gorm.DB, userID uint, items []models.OrderItem) error {
+	var ids []uint
+	for _, it := range items {
+		if it.ItemType == models.ItemTypeProduct {
+			ids = append(ids, it.ItemID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var products []models.Product
+	if err := tx.Select("id", "purchase_limit").Where("id IN ? AND purchase_limit > 0", ids).Find(&products).Error; err != nil {
+		return err
+	}
+	limits := make(map[uint]int, len(products))
+	bought := make(map[uint]int, len(products))
+	for _, p := range products {
+		n, err := purchasedQuantity(tx, userID, p.ID)
+		if err != nil {
+			return err
+		}
+		limits[p.ID] = p.PurchaseLimit
+		bought[p.ID] = n
+	}
+	return checkPurchaseLimits(items, limits, bought)
+}
@@ -0,0 +1,366 @@
+package handlers
+
+import (
+	"cursed_backend/internal/db"
+	"cursed_backend/internal/logger"
+	"cursed_backend/internal/models"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errPurchaseOrderMissing  = &purchaseError{http.StatusNotFound, "Purchase order not found"}
+	errPurchaseOrderStatus   = &purchaseError{http.StatusConflict, "Purchase order cannot be changed in its current status"}
+	errPurchaseOrderSupplier = &purchaseError{http.StatusBadRequest, "Supplier not found"}
+	errPurchaseOrderProduct  = &purchaseError{http.StatusBadRequest, "Purchase order lines must reference store products"}
+	errPurchaseOrderLine     = &purchaseError{http.StatusBadRequest, "Line does not belong to this purchase order"}
+	errOverReceipt           = &purchaseError{http.StatusBadRequest, "Received quantity exceeds what is outstanding on the line"}
+)
+
+type purchaseOrderRequest struct {
+	SupplierID uint   `json:"supplierId" validate:"required"`
+	Reference  string `json:"reference" validate:"omitempty,max=50"`
+	Notes      string `json:"notes" validate:"omitempty,max=500"`
+	Lines      []struct {
+		ProductID uint         `json:"productId" validate:"required"`
+		VariantID uint         `json:"variantId"`
+		Quantity  int          `json:"quantity" validate:"required,min=1"`
+		UnitCost  models.Money `json:"unitCost" validate:"gte=0"`
+	} `json:"lines" validate:"required,min=1,dive"`
+}
+
+// bindPurchaseOrder validates the request and checks that the supplier and
+// every product exist, returning the order it describes.
+func bindPurchaseOrder(c *gin.Context) (*models.PurchaseOrder, bool) {
+	var req purchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return nil, false
+	}
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return nil, false
+	}
+
+	var supplier models.Supplier
+	if err := db.GormDB.First(&supplier, req.SupplierID).Error; err != nil {
+		writePurchaseError(c, errPurchaseOrderSupplier, "")
+		return nil, false
+	}
+
+	po := &models.PurchaseOrder{SupplierID: supplier.ID, Reference: req.Reference, Notes: req.Notes}
+	for _, l := range req.Lines {
+		var product models.Product
+		if err := db.GormDB.First(&product, "id = ? AND owner_id = 0", l.ProductID).Error; err != nil {
+			writePurchaseError(c, errPurchaseOrderProduct, "")
+			return nil, false
+		}
+		if _, err := findVariant(db.GormDB, &product, l.VariantID, false); err != nil {
+			writePurchaseError(c, err, "Failed to check products")
+			return nil, false
+		}
+		po.Lines = append(po.Lines, models.PurchaseOrderLine{ProductID: l.ProductID, VariantID: l.VariantID, QuantityOrdered: l.Quantity, UnitCost: l.UnitCost})
+		po.Total += l.UnitCost.Times(l.Quantity)
+	}
+	return po, true
+}
+
+func GetPurchaseOrders(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var orders []models.PurchaseOrder
+	query := db.GormDB.Preload("Lines")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if supplierIDStr := c.Query("supplier_id"); supplierIDStr != "" {
+		supplierID, err := strconv.ParseUint(supplierIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Invalid supplier ID"})
+			return
+		}
+		query = query.Where("supplier_id = ?", supplierID)
+	}
+
+	if err := query.Order("created_at DESC").Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch purchase orders"})
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: orders})
+}
+
+func GetPurchaseOrder(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var po models.PurchaseOrder
+	if err := db.GormDB.Preload("Lines").First(&po, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: errPurchaseOrderMissing.message})
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: po})
+}
+
+func CreatePurchaseOrder(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	po, ok := bindPurchaseOrder(c)
+	if !ok {
+		return
+	}
+	po.Status = models.PurchaseOrderStatusDraft
+	po.CreatedBy = c.GetUint("user_id")
+	if err := db.GormDB.Create(po).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to create purchase order"})
+		return
+	}
+
+	logger.AuditLog("purchase_order_created", po.CreatedBy, c.ClientIP(), nil)
+	c.JSON(http.StatusCreated, models.APIResponse{Success: true, Data: po})
+}
+
+// UpdatePurchaseOrder replaces the supplier, notes and lines of a draft.
+func UpdatePurchaseOrder(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	input, ok := bindPurchaseOrder(c)
+	if !ok {
+		return
+	}
+
+	var po models.PurchaseOrder
+	err := db.GormDB.Transaction(func(tx *gorm.DB) error {
+		if err := lockPurchaseOrder(tx, &po, uint(id)); err != nil {
+			return err
+		}
+		if po.Status != models.PurchaseOrderStatusDraft {
+			return errPurchaseOrderStatus
+		}
+		if err := tx.Where("purchase_order_id = ?", po.ID).Delete(&models.PurchaseOrderLine{}).Error; err != nil {
+			return err
+		}
+		for i := range input.Lines {
+			input.Lines[i].PurchaseOrderID = po.ID
+		}
+		if err := tx.Create(&input.Lines).Error; err != nil {
+			return err
+		}
+		po.SupplierID = input.SupplierID
+		po.Reference = input.Reference
+		po.Notes = input.Notes
+		po.Total = input.Total
+		po.Lines = input.Lines
+		return tx.Omit("Lines").Save(&po).Error
+	})
+	if err != nil {
+		writePurchaseError(c, err, "Update failed")
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: po})
+}
+
+func SendPurchaseOrder(c *gin.Context) {
+	transitionPurchaseOrder(c, []string{models.PurchaseOrderStatusDraft}, models.PurchaseOrderStatusSent, "purchase_order_sent")
+}
+
+func CancelPurchaseOrder(c *gin.Context) {
+	transitionPurchaseOrder(c, []string{models.PurchaseOrderStatusDraft, models.PurchaseOrderStatusSent}, models.PurchaseOrderStatusCancelled, "purchase_order_cancelled")
+}
+
+// transitionPurchaseOrder moves the order in the :id path parameter to status
+// if it is currently in one of from.
+func transitionPurchaseOrder(c *gin.Context, from []string, status, action string) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	updates := map[string]interface{}{"status": status}
+	if status == models.PurchaseOrderStatusSent {
+		updates["sent_at"] = time.Now()
+	}
+	result := db.GormDB.Model(&models.PurchaseOrder{}).Where("id = ? AND status IN ?", id, from).Updates(updates)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Update failed"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, models.APIResponse{Success: false, Message: "Purchase order not found or cannot be changed in its current status"})
+		return
+	}
+
+	var po models.PurchaseOrder
+	if err := db.GormDB.Preload("Lines").First(&po, id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to refresh purchase order"})
+		return
+	}
+
+	logger.AuditLog(action, c.GetUint("user_id"), c.ClientIP(), nil)
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: po})
+}
+
+// ReceivePurchaseOrder books a delivery against a sent order. Stock of each
+// product goes up by the received quantity and its cost price is set from
+// the line, all in one transaction.
+func ReceivePurchaseOrder(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var req struct {
+		Lines []struct {
+			LineID   uint `json:"lineId" validate:"required"`
+			Quantity int  `json:"quantity" validate:"required,min=1"`
+		} `json:"lines" validate:"required,min=1,dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	received := map[uint]int{}
+	for _, l := range req.Lines {
+		received[l.LineID] += l.Quantity
+	}
+
+	var po models.PurchaseOrder
+	var restocked []models.Product
+	var previousStock []int
+	err := db.GormDB.Transaction(func(tx *gorm.DB) error {
+		if err := lockPurchaseOrder(tx, &po, uint(id)); err != nil {
+			return err
+		}
+		if po.Status != models.PurchaseOrderStatusSent && po.Status != models.PurchaseOrderStatusPartiallyReceived {
+			return errPurchaseOrderStatus
+		}
+		if err := tx.Where("purchase_order_id = ?", po.ID).Order("id").Find(&po.Lines).Error; err != nil {
+			return err
+		}
+		lines := map[uint]*models.PurchaseOrderLine{}
+		for i := range po.Lines {
+			lines[po.Lines[i].ID] = &po.Lines[i]
+		}
+
+		// Lock products in id order, as purchases do, to avoid deadlocks
+		var receiving []*models.PurchaseOrderLine
+		for lineID, qty := range received {
+			line, ok := lines[lineID]
+			if !ok {
+				return errPurchaseOrderLine
+			}
+			if line.QuantityReceived+qty > line.QuantityOrdered {
+				return errOverReceipt
+			}
+			receiving = append(receiving, line)
+		}
+		sort.Slice(receiving, func(i, j int) bool {
+			if receiving[i].ProductID != receiving[j].ProductID {
+				return receiving[i].ProductID < receiving[j].ProductID
+			}
+			return receiving[i].VariantID < receiving[j].VariantID
+		})
+
+		for _, line := range receiving {
+			qty := received[line.ID]
+			var product models.Product
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, "id = ? AND owner_id = 0", line.ProductID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errPurchaseOrderProduct
+				}
+				return err
+			}
+			if line.VariantID != 0 {
+				variant, err := lockVariant(tx, &product, line.VariantID)
+				if err != nil {
+					return err
+				}
+				if err := tx.Model(variant).Update("stock", gorm.Expr("stock + ?", qty)).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Model(&product).Updates(map[string]interface{}{
+				"stock":      gorm.Expr("stock + ?", qty),
+				"cost_price": line.UnitCost,
+			}).Error; err != nil {
+				return err
+			}
+			if err := recordStockMovement(tx, product.ID, line.VariantID, qty, models.StockReasonPurchaseOrder, c.GetUint("user_id"), relatedPurchaseOrder, po.ID); err != nil {
+				return err
+			}
+			previousStock = append(previousStock, product.Stock)
+			product.Stock += qty
+			product.CostPrice = line.UnitCost
+			restocked = append(restocked, product)
+
+			line.QuantityReceived += qty
+			if err := tx.Model(line).Update("quantity_received", line.QuantityReceived).Error; err != nil {
+				return err
+			}
+		}
+
+		po.Status = models.PurchaseOrderStatusReceived
+		for _, line := range po.Lines {
+			if line.QuantityReceived < line.QuantityOrdered {
+				po.Status = models.PurchaseOrderStatusPartiallyReceived
+				break
+			}
+		}
+		updates := map[string]interface{}{"status": po.Status}
+		if po.Status == models.PurchaseOrderStatusReceived {
+			now := time.Now()
+			po.ReceivedAt = &now
+			updates["received_at"] = now
+		}
+		return tx.Model(&po).Updates(updates).Error
+	})
+	if err != nil {
+		writePurchaseError(c, err, "Receiving failed")
+		return
+	}
+
+	for i := range restocked {
+		afterRestock(&restocked[i], previousStock[i])
+	}
+	logger.AuditLog("purchase_order_received", c.GetUint("user_id"), c.ClientIP(), nil)
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Delivery received", Data: po})
+}
+
+func lockPurchaseOrder(tx *gorm.DB, po *models.PurchaseOrder, id uint) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(po, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errPurchaseOrderMissing
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"cursed_backend/internal/models"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestCheckPurchaseLimits(t *testing.T) {
+	line := func(itemType string, itemID, variantID uint, qty int) models.OrderItem {
+		return models.OrderItem{ItemType: itemType, ItemID: itemID, VariantID: variantID, Quantity: qty}
+	}
+	twoVariants := []models.OrderItem{
+		line(models.ItemTypeProduct, 1, 10, 2),
+		line(models.ItemTypeProduct, 1, 11, 2),
+	}
+
+	tests := []struct {
+		name    string
+		items   []models.OrderItem
+		limits  map[uint]int
+		bought  map[uint]int
+		wantErr bool
+	}{
+		{"two variants within the limit", twoVariants, map[uint]int{1: 4}, nil, false},
+		{"two variants over the limit together", twoVariants, map[uint]int{1: 3}, nil, true},
+		{"earlier purchases count", twoVariants, map[uint]int{1: 4}, map[uint]int{1: 1}, true},
+		{"unlimited product", twoVariants, nil, nil, false},
+		{"bundle components add up", []models.OrderItem{
+			line(models.ItemTypeBundle, 5, 0, 1),
+			line(models.ItemTypeProduct, 1, 0, 1),
+			line(models.ItemTypeProduct, 1, 0, 1),
+		}, map[uint]int{1: 1}, nil, true},
+		{"pet with the same ID is not counted", []models.OrderItem{
+			line(models.ItemTypePet, 1, 0, 1),
+			line(models.ItemTypeProduct, 1, 0, 1),
+		}, map[uint]int{1: 1}, nil, false},
+		{"only the limited product is checked", []models.OrderItem{
+			line(models.ItemTypeProduct, 1, 0, 1),
+			line(models.ItemTypeProduct, 2, 0, 9),
+		}, map[uint]int{1: 1}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkPurchaseLimits(tt.items, tt.limits, tt.bought)
+			if tt.wantErr != errors.Is(err, errPurchaseLimit) || (!tt.wantErr && err != nil) {
+				t.Errorf("checkPurchaseLimits() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsUniqueViolation(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"duplicate key", &pgconn.PgError{Code: "23505"}, true},
+		{"wrapped duplicate key", fmt.Errorf("save: %w", &pgconn.PgError{Code: "23505"}), true},
+		{"check violation", &pgconn.PgError{Code: "23514"}, false},
+		{"other error", errors.New("connection reset"), false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isUniqueViolation(tt.err); got != tt.want {
+				t.Errorf("isUniqueViolation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"cursed_backend/internal/db"
+	"cursed_backend/internal/models"
+	"cursed_backend/internal/recommend"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultRecommendationLimit = 10
+	maxRecommendationLimit     = 50
+)
+
+type recommendationView struct {
+	Product models.Product `json:"product"`
+	Score   float64        `json:"score"`
+	Reasons []string       `json:"reasons"`
+}
+
+func MyRecommendations(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	limit := defaultRecommendationLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxRecommendationLimit {
+			c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "limit must be between 1 and " + strconv.Itoa(maxRecommendationLimit)})
+			return
+		}
+		limit = n
+	}
+	userID := c.GetUint("user_id")
+
+	var pets []models.Pet
+	if err := db.GormDB.Where("owner_id = ?", userID).Find(&pets).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to load pets"})
+		return
+	}
+	profiles := make([]recommend.Pet, 0, len(pets))
+	for _, p := range pets {
+		profiles = append(profiles, recommend.Pet{Species: p.Species, BreedSize: p.BreedSize, Age: p.Age})
+	}
+
+	// Store products the user has bought before; they are not recommended again
+	var bought []uint
+	if err := db.GormDB.Model(&models.OrderItem{}).
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("orders.user_id = ? AND order_items.item_type = ?", userID, models.ItemTypeProduct).
+		Distinct().Pluck("order_items.item_id", &bought).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to load order history"})
+		return
+	}
+
+	// Count other customers' orders where a product appears next to one the user bought
+	coPurchases := map[uint]int{}
+	if len(bought) > 0 {
+		var rows []struct {
+			ProductID uint
+			Orders    int
+		}
+		if err := db.GormDB.Model(&models.OrderItem{}).
+			Select("order_items.item_id AS product_id, COUNT(DISTINCT order_items.order_id) AS orders").
+			Joins("JOIN orders ON orders.id = order_items.order_id").
+			Where("orders.user_id <> ? AND order_items.item_type = ? AND order_items.item_id NOT IN ?", userID, models.ItemTypeProduct, bought).
+			Where("EXISTS (SELECT 1 FROM order_items mine WHERE mine.order_id = order_items.order_id AND mine.item_type = ? AND mine.item_id IN ?)", models.ItemTypeProduct, bought).
+			Group("order_items.item_id").
+			Scan(&rows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to load purchase history"})
+			return
+		}
+		for _, r := range rows {
+			coPurchases[r.ProductID] = r.Orders
+		}
+	}
+
+	query := db.GormDB.Where("owner_id = 0 AND stock > 0")
+	if len(bought) > 0 {
+		query = query.Where("id NOT IN ?", bought)
+	}
+	var products []models.Product
+	if err := query.Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to load products"})
+		return
+	}
+
+	candidates := make([]recommend.Candidate, 0, len(products))
+	byID := make(map[uint]models.Product, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+		candidates = append(candidates, recommend.Candidate{
+			ProductID:   p.ID,
+			Species:     p.Species,
+			BreedSize:   p.BreedSize,
+			MinAge:      p.MinPetAge,
+			MaxAge:      p.MaxPetAge,
+			CoPurchases: coPurchases[p.ID],
+		})
+	}
+
+	ranked := recommend.Rank(profiles, candidates, limit)
+	views := make([]recommendationView, 0, len(ranked))
+	for _, r := range ranked {
+		product := byID[r.ProductID]
+		hideStaffFields(&product)
+		views = append(views, recommendationView{Product: product, Score: r.Score, Reasons: r.Reasons})
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: views})
+}
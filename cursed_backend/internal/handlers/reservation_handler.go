@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"cursed_backend/internal/db"
+	"cursed_backend/internal/jobs"
+	"cursed_backend/internal/logger"
+	"cursed_backend/internal/models"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errPetReserved    = &purchaseError{http.StatusConflict, "Pet is reserved by another customer"}
+	errTooManyHolds   = &purchaseError{http.StatusConflict, "You already hold the maximum number of pets"}
+	errNoReservation  = &purchaseError{http.StatusNotFound, "No active reservation on this pet"}
+	errNotReservation = &purchaseError{http.StatusForbidden, "Not your reservation"}
+)
+
+func ReservePet(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	userID := c.GetUint("user_id")
+
+	var pet models.Pet
+	err := db.GormDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&pet, "id = ? AND owner_id = 0", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errPetUnavailable
+			}
+			return err
+		}
+		now := time.Now()
+		if pet.HeldByOther(userID, now) {
+			return errPetReserved
+		}
+		if pet.ReservedBy == userID && pet.ReservedUntil != nil && pet.ReservedUntil.After(now) {
+			// Already held by this user; holds are not extended by re-reserving
+			return nil
+		}
+
+		var holds int64
+		if err := tx.Model(&models.Pet{}).Where("reserved_by = ? AND reserved_until > ? AND owner_id = 0", userID, now).Count(&holds).Error; err != nil {
+			return err
+		}
+		if appConfig.PetMaxHoldsPerUser > 0 && int(holds) >= appConfig.PetMaxHoldsPerUser {
+			return errTooManyHolds
+		}
+
+		until := now.Add(appConfig.PetHoldDuration)
+		pet.ReservedBy = userID
+		pet.ReservedUntil = &until
+		return tx.Model(&pet).Updates(map[string]interface{}{"reserved_by": userID, "reserved_until": until}).Error
+	})
+	if err != nil {
+		writePurchaseError(c, err, "Reservation failed")
+		return
+	}
+
+	logger.AuditLog("pet_reserved", userID, c.ClientIP(), nil)
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Pet reserved", Data: pet})
+}
+
+func ReleasePet(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	userID := c.GetUint("user_id")
+	role := c.GetString("role")
+
+	var pet models.Pet
+	err := db.GormDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&pet, "id = ? AND reserved_by <> 0", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errNoReservation
+			}
+			return err
+		}
+		if pet.ReservedBy != userID && role != "manager" && role != "admin" {
+			return errNotReservation
+		}
+		pet.ReservedBy = 0
+		pet.ReservedUntil = nil
+		return tx.Model(&pet).Updates(map[string]interface{}{"reserved_by": 0, "reserved_until": nil}).Error
+	})
+	if err != nil {
+		writePurchaseError(c, err, "Release failed")
+		return
+	}
+
+	logger.AuditLog("pet_reservation_released", userID, c.ClientIP(), nil)
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Reservation released", Data: pet})
+}
+
+// ReleaseExpiredReservations clears holds whose expiry has passed.
+func ReleaseExpiredReservations() error {
+	if db.GormDB == nil {
+		return errors.New("database not available")
+	}
+	result := db.GormDB.Model(&models.Pet{}).
+		Where("reserved_by <> 0 AND reserved_until <= ?", time.Now()).
+		Updates(map[string]interface{}{"reserved_by": 0, "reserved_until": nil})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		logger.Log.WithField("released", result.RowsAffected).Info("Expired pet reservations released")
+	}
+	return nil
+}
+
+// StartReservationSweeper releases expired holds every interval until ctx is done.
+func StartReservationSweeper(ctx context.Context, interval time.Duration) {
+	jobs.RunEvery(ctx, "reservation_sweeper", interval, ReleaseExpiredReservations)
+}
@@ -0,0 +1,471 @@
+package handlers
+
+import (
+	"cursed_backend/internal/db"
+	"cursed_backend/internal/logger"
+	"cursed_backend/internal/models"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errReturnNotPending     = &purchaseError{http.StatusConflict, "Return request already decided"}
+	errReturnQuantity       = &purchaseError{http.StatusBadRequest, "Return quantity exceeds what is left on the order item"}
+	errReturnItemNotOwned   = &purchaseError{http.StatusConflict, "Item is no longer owned by the customer"}
+	errReturnOwnedQuantity  = &purchaseError{http.StatusConflict, "Customer no longer holds the quantity being returned"}
+	errReturnRequestMissing = &purchaseError{http.StatusNotFound, "Return request not found"}
+)
+
+// returnableQuantity is what is left on an order item once completed and
+// pending returns are taken off.
+func returnableQuantity(tx *gorm.DB, item *models.OrderItem) (int, error) {
+	var pending int64
+	if err := tx.Model(&models.ReturnRequest{}).
+		Where("order_item_id = ? AND status = ?", item.ID, models.ReturnStatusPending).
+		Select("COALESCE(SUM(quantity), 0)").Scan(&pending).Error; err != nil {
+		return 0, err
+	}
+	return item.Quantity - item.Returned - int(pending), nil
+}
+
+func CreateReturn(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var req struct {
+		OrderItemID uint   `json:"orderItemId" validate:"required"`
+		Quantity    int    `json:"quantity" validate:"omitempty,min=1"`
+		Condition   string `json:"condition" validate:"omitempty,oneof=unopened opened damaged"`
+		Reason      string `json:"reason" validate:"required,min=3,max=500"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
+	userID := c.GetUint("user_id")
+	var item models.OrderItem
+	if err := db.GormDB.First(&item, req.OrderItemID).Error; err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: "Order item not found"})
+		return
+	}
+	var order models.Order
+	if err := db.GormDB.First(&order, "id = ? AND user_id = ?", item.OrderID, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: "Order item not found"})
+		return
+	}
+	if item.ItemType == models.ItemTypeBundle || item.BundleID != 0 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Bundles cannot be returned"})
+		return
+	}
+
+	if time.Since(order.CreatedAt) > time.Duration(appConfig.ReturnWindowDays)*24*time.Hour {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Return window has closed"})
+		return
+	}
+	if item.ItemType == models.ItemTypeProduct {
+		if req.Condition == "" {
+			c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Condition is required for product returns"})
+			return
+		}
+		if !slices.Contains(appConfig.ReturnConditions, req.Condition) {
+			c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Items in " + req.Condition + " condition cannot be returned"})
+			return
+		}
+	} else {
+		req.Condition = ""
+	}
+
+	remaining, err := returnableQuantity(db.GormDB, &item)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to check order item"})
+		return
+	}
+	if req.Quantity > remaining {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: errReturnQuantity.message})
+		return
+	}
+
+	ret := models.ReturnRequest{
+		UserID:      userID,
+		OrderID:     order.ID,
+		OrderItemID: item.ID,
+		Quantity:    req.Quantity,
+		Condition:   req.Condition,
+		Reason:      req.Reason,
+		Status:      models.ReturnStatusPending,
+	}
+	if err := db.GormDB.Create(&ret).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to create return request"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{Success: true, Data: ret})
+}
+
+func MyReturns(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var returns []models.ReturnRequest
+	userID := c.GetUint("user_id")
+
+	if err := db.GormDB.Where("user_id = ?", userID).Order("created_at DESC").Find(&returns).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch return requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: returns})
+}
+
+func GetReturns(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var returns []models.ReturnRequest
+	query := db.GormDB
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Order("created_at").Find(&returns).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch return requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: returns})
+}
+
+func ApproveReturn(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var req struct {
+		Note string `json:"note" validate:"omitempty,max=500"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	reviewerID := c.GetUint("user_id")
+
+	var ret models.ReturnRequest
+	err := db.GormDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ret, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errReturnRequestMissing
+			}
+			return err
+		}
+		if ret.Status != models.ReturnStatusPending {
+			return errReturnNotPending
+		}
+
+		var item models.OrderItem
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, ret.OrderItemID).Error; err != nil {
+			return err
+		}
+		if item.Quantity-item.Returned < ret.Quantity {
+			return errReturnQuantity
+		}
+
+		restocked, err := restoreInventory(tx, &ret, &item, reviewerID)
+		if err != nil {
+			return err
+		}
+		if err := releaseFlashSaleUnits(tx, &item, ret.Quantity, time.Now()); err != nil {
+			return err
+		}
+
+		note := "Return #" + strconv.FormatUint(uint64(ret.ID), 10)
+		refund, err := settleReturnPayments(tx, &item, ret.Quantity, reviewerID, note)
+		if err != nil {
+			return err
+		}
+		if refund > 0 {
+			if _, err := applyWalletChange(tx, ret.UserID, refund, models.WalletTxRefund, ret.OrderID, reviewerID, note); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&item).Update("returned", gorm.Expr("returned + ?", ret.Quantity)).Error; err != nil {
+			return err
+		}
+		if err := updateOrderRefundStatus(tx, ret.OrderID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		ret.Status = models.ReturnStatusApproved
+		ret.Restocked = restocked
+		ret.RefundAmount = refund
+		ret.ReviewerID = reviewerID
+		ret.DecisionNote = req.Note
+		ret.DecidedAt = &now
+		return tx.Save(&ret).Error
+	})
+	if err != nil {
+		writePurchaseError(c, err, "Return approval failed")
+		return
+	}
+
+	logger.AuditLog("return_approved", ret.UserID, c.ClientIP(), nil)
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Return approved", Data: ret})
+}
+
+func RejectReturn(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var req struct {
+		Note string `json:"note" validate:"required,min=3,max=500"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	now := time.Now()
+	result := db.GormDB.Model(&models.ReturnRequest{}).
+		Where("id = ? AND status = ?", id, models.ReturnStatusPending).
+		Updates(map[string]interface{}{
+			"status":        models.ReturnStatusRejected,
+			"reviewer_id":   c.GetUint("user_id"),
+			"decision_note": req.Note,
+			"decided_at":    now,
+		})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Return rejection failed"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, models.APIResponse{Success: false, Message: "Return request not found or already decided"})
+		return
+	}
+
+	var ret models.ReturnRequest
+	if err := db.GormDB.First(&ret, id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to refresh return request"})
+		return
+	}
+
+	logger.AuditLog("return_rejected", ret.UserID, c.ClientIP(), nil)
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Return rejected", Data: ret})
+}
+
+// restoreInventory takes the returned quantity back from the customer and,
+// when the condition allows it, puts it back on sale. It reports whether the
+// store inventory was restocked.
+func restoreInventory(tx *gorm.DB, ret *models.ReturnRequest, item *models.OrderItem, actorID uint) (bool, error) {
+	if item.ItemType == models.ItemTypePet {
+		result := tx.Model(&models.Pet{}).Where("id = ? AND owner_id = ?", item.OwnedItemID, ret.UserID).Update("owner_id", 0)
+		if result.Error != nil {
+			return false, result.Error
+		}
+		if result.RowsAffected == 0 {
+			return false, errReturnItemNotOwned
+		}
+		return true, nil
+	}
+
+	restock := slices.Contains(appConfig.ReturnRestockConditions, ret.Condition)
+	if restock {
+		var err error
+		if restock, err = restockStore(tx, item, ret.Quantity); err != nil {
+			return false, err
+		}
+		if restock {
+			if err := recordStockMovement(tx, item.ItemID, item.VariantID, ret.Quantity, models.StockReasonReturn, actorID, relatedReturn, ret.ID); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	var owned models.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&owned, "id = ? AND owner_id = ?", item.OwnedItemID, ret.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, errReturnItemNotOwned
+		}
+		return false, err
+	}
+	if owned.Stock < ret.Quantity {
+		return false, errReturnOwnedQuantity
+	}
+	if owned.Stock == ret.Quantity {
+		if err := tx.Delete(&owned).Error; err != nil {
+			return false, err
+		}
+	} else if err := tx.Model(&owned).Update("stock", gorm.Expr("stock - ?", ret.Quantity)).Error; err != nil {
+		return false, err
+	}
+
+	return restock, nil
+}
+
+// restockStore puts quantity units of an order item back on the store listing
+// and variant it was bought from. It reports false when either has since been
+// deleted, leaving nothing to put the units back on.
+func restockStore(tx *gorm.DB, item *models.OrderItem, quantity int) (bool, error) {
+	// Lock the store rows before the owned copy, in the same order purchases do
+	var storeProduct models.Product
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&storeProduct, "id = ? AND owner_id = 0", item.ItemID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if item.VariantID != 0 {
+		variant, err := lockVariant(tx, &storeProduct, item.VariantID)
+		if errors.Is(err, errVariantUnavailable) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		if err := tx.Model(variant).Update("stock", gorm.Expr("stock + ?", quantity)).Error; err != nil {
+			return false, err
+		}
+	}
+	if err := tx.Model(&storeProduct).Update("stock", gorm.Expr("stock + ?", quantity)).Error; err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// returnSplit is how the value of a return goes back to the customer.
+type returnSplit struct {
+	wallet         models.Money
+	giftCard       models.Money
+	pointsRestored int64
+	pointsReversed int64
+}
+
+// splitReturn works out the split for returning qty units of item. When the
+// order was partly paid with loyalty points or a gift card, the same share
+// goes back through those: the card is credited, redeemed points are given
+// back and earned points taken back. Shares are computed on the running total
+// of returned units, so rounding never drifts and a fully returned order
+// settles exactly. order.Items must not include this return yet.
+func splitReturn(order *models.Order, item *models.OrderItem, qty int) returnSplit {
+	share := item.UnitPrice.Times(qty)
+	if order.PointsRedeemed == 0 && order.PointsEarned == 0 && order.GiftCardAmount == 0 {
+		return returnSplit{wallet: share}
+	}
+
+	var gross, before int64
+	for _, it := range order.Items {
+		gross += int64(it.UnitPrice.Times(it.Quantity))
+		before += int64(it.UnitPrice.Times(it.Returned))
+	}
+	if gross == 0 {
+		return returnSplit{}
+	}
+	after := before + int64(share)
+	portion := func(total int64) int64 {
+		return total*after/gross - total*before/gross
+	}
+
+	split := returnSplit{
+		wallet:         share,
+		giftCard:       models.Money(portion(int64(order.GiftCardAmount))),
+		pointsRestored: portion(order.PointsRedeemed),
+		pointsReversed: portion(order.PointsEarned),
+	}
+	if order.PointsDiscount > 0 || order.GiftCardAmount > 0 {
+		split.wallet = models.Money(portion(int64(order.Total)))
+	}
+	return split
+}
+
+// settleReturnPayments credits the gift card and loyalty parts of a return
+// as splitReturn decides and returns the part left for the wallet. The gift
+// card part goes to the wallet too when the card can no longer take it. Must
+// run before item.Returned is updated.
+func settleReturnPayments(tx *gorm.DB, item *models.OrderItem, qty int, actorID uint, note string) (models.Money, error) {
+	var order models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&order, item.OrderID).Error; err != nil {
+		return 0, err
+	}
+	split := splitReturn(&order, item, qty)
+
+	if split.giftCard > 0 {
+		refunded, err := refundGiftCard(tx, &order, split.giftCard, actorID)
+		if err != nil {
+			return 0, err
+		}
+		if !refunded {
+			split.wallet += split.giftCard
+		}
+	}
+	if split.pointsRestored > 0 {
+		if _, err := applyLoyaltyChange(tx, order.UserID, split.pointsRestored, models.LoyaltyTxRestore, order.ID, actorID, note); err != nil {
+			return 0, err
+		}
+	}
+	if split.pointsReversed > 0 {
+		if _, err := applyLoyaltyChange(tx, order.UserID, -split.pointsReversed, models.LoyaltyTxReversal, order.ID, actorID, note); err != nil {
+			return 0, err
+		}
+	}
+	return split.wallet, nil
+}
+
+// orderRefundStatus is refunded once every item has been fully returned, and
+// partially refunded otherwise.
+func orderRefundStatus(items []models.OrderItem) string {
+	for _, it := range items {
+		if it.Returned < it.Quantity {
+			return models.OrderStatusPartiallyRefunded
+		}
+	}
+	return models.OrderStatusRefunded
+}
+
+// updateOrderRefundStatus sets the order status after a return.
+func updateOrderRefundStatus(tx *gorm.DB, orderID uint) error {
+	var items []models.OrderItem
+	if err := tx.Where("order_id = ?", orderID).Find(&items).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Order{}).Where("id = ?", orderID).Update("status", orderRefundStatus(items)).Error
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"cursed_backend/internal/models"
+	"testing"
+)
+
+// returnStep returns qty units of order.Items[item] and checks the split.
+type returnStep struct {
+	item int
+	qty  int
+	want returnSplit
+}
+
+func runReturns(t *testing.T, order models.Order, steps []returnStep) returnSplit {
+	t.Helper()
+	var total returnSplit
+	for i, s := range steps {
+		got := splitReturn(&order, &order.Items[s.item], s.qty)
+		if got != s.want {
+			t.Fatalf("return %d: splitReturn() = %+v, want %+v", i+1, got, s.want)
+		}
+		order.Items[s.item].Returned += s.qty
+		total.wallet += got.wallet
+		total.giftCard += got.giftCard
+		total.pointsRestored += got.pointsRestored
+		total.pointsReversed += got.pointsReversed
+	}
+	return total
+}
+
+func TestSplitReturnWalletOnly(t *testing.T) {
+	order := models.Order{
+		Total: 2500,
+		Items: []models.OrderItem{{UnitPrice: 1000, Quantity: 2}, {UnitPrice: 500, Quantity: 1}},
+	}
+	runReturns(t, order, []returnStep{
+		{0, 1, returnSplit{wallet: 1000}},
+		{1, 1, returnSplit{wallet: 500}},
+	})
+}
+
+func TestSplitReturnMixedPayment(t *testing.T) {
+	// 2500 of goods paid with a 1000 gift card, 500 points worth 500 and 1000
+	// from the wallet, which earned 10 points
+	order := models.Order{
+		Total:          1000,
+		GiftCardAmount: 1000,
+		PointsRedeemed: 500,
+		PointsDiscount: 500,
+		PointsEarned:   10,
+		Items:          []models.OrderItem{{UnitPrice: 1000, Quantity: 2}, {UnitPrice: 500, Quantity: 1}},
+	}
+	total := runReturns(t, order, []returnStep{
+		{0, 1, returnSplit{wallet: 400, giftCard: 400, pointsRestored: 200, pointsReversed: 4}},
+		{0, 1, returnSplit{wallet: 400, giftCard: 400, pointsRestored: 200, pointsReversed: 4}},
+		{1, 1, returnSplit{wallet: 200, giftCard: 200, pointsRestored: 100, pointsReversed: 2}},
+	})
+	want := returnSplit{wallet: 1000, giftCard: 1000, pointsRestored: 500, pointsReversed: 10}
+	if total != want {
+		t.Errorf("full return = %+v, want everything back: %+v", total, want)
+	}
+}
+
+func TestSplitReturnRoundingSettlesExactly(t *testing.T) {
+	order := models.Order{
+		Total:          2000,
+		GiftCardAmount: 1000,
+		Items:          []models.OrderItem{{UnitPrice: 1000, Quantity: 3}},
+	}
+	total := runReturns(t, order, []returnStep{
+		{0, 1, returnSplit{wallet: 666, giftCard: 333}},
+		{0, 1, returnSplit{wallet: 667, giftCard: 333}},
+		{0, 1, returnSplit{wallet: 667, giftCard: 334}},
+	})
+	if total.wallet != order.Total || total.giftCard != order.GiftCardAmount {
+		t.Errorf("full return = %+v, want wallet %d and gift card %d", total, order.Total, order.GiftCardAmount)
+	}
+}
+
+func TestSplitReturnEarnedPointsOnly(t *testing.T) {
+	// Without a discount the wallet paid the full price and gets the full share
+	order := models.Order{
+		Total:        3000,
+		PointsEarned: 30,
+		Items:        []models.OrderItem{{UnitPrice: 1000, Quantity: 3}},
+	}
+	runReturns(t, order, []returnStep{
+		{0, 2, returnSplit{wallet: 2000, pointsReversed: 20}},
+		{0, 1, returnSplit{wallet: 1000, pointsReversed: 10}},
+	})
+}
+
+func TestSplitReturnFreeOrder(t *testing.T) {
+	order := models.Order{
+		GiftCardAmount: 0,
+		PointsRedeemed: 100,
+		PointsDiscount: 100,
+		Items:          []models.OrderItem{{UnitPrice: 0, Quantity: 1}},
+	}
+	runReturns(t, order, []returnStep{{0, 1, returnSplit{}}})
+}
+
+func TestOrderRefundStatus(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []models.OrderItem
+		want  string
+	}{
+		{"everything returned", []models.OrderItem{{Quantity: 2, Returned: 2}, {Quantity: 1, Returned: 1}}, models.OrderStatusRefunded},
+		{"one line partly returned", []models.OrderItem{{Quantity: 2, Returned: 1}, {Quantity: 1, Returned: 1}}, models.OrderStatusPartiallyRefunded},
+		{"one line untouched", []models.OrderItem{{Quantity: 2, Returned: 2}, {Quantity: 1}}, models.OrderStatusPartiallyRefunded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := orderRefundStatus(tt.items); got != tt.want {
+				t.Errorf("orderRefundStatus() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
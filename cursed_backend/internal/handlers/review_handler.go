@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"cursed_backend/internal/db"
+	"cursed_backend/internal/logger"
+	"cursed_backend/internal/models"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/microcosm-cc/bluemonday"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const sortByRating = "rating"
+
+type ratingSummary struct {
+	ProductID uint
+	Average   float64
+	Count     int64
+}
+
+// ratingSummaries averages the visible reviews of the given store products.
+func ratingSummaries(tx *gorm.DB, productIDs []uint) (map[uint]ratingSummary, error) {
+	out := map[uint]ratingSummary{}
+	if len(productIDs) == 0 {
+		return out, nil
+	}
+	var rows []ratingSummary
+	if err := tx.Model(&models.Review{}).
+		Select("product_id, AVG(rating) AS average, COUNT(*) AS count").
+		Where("product_id IN ? AND hidden = false", productIDs).
+		Group("product_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		r.Average = math.Round(r.Average*100) / 100
+		out[r.ProductID] = r
+	}
+	return out, nil
+}
+
+// attachRatings fills in rating summaries. Owned copies show the rating of
+// the store product they were bought from.
+func attachRatings(tx *gorm.DB, products []models.Product) error {
+	ids := make([]uint, 0, len(products))
+	for i := range products {
+		ids = append(ids, reviewedProductID(&products[i]))
+	}
+	summaries, err := ratingSummaries(tx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range products {
+		s := summaries[reviewedProductID(&products[i])]
+		products[i].AverageRating = s.Average
+		products[i].ReviewCount = s.Count
+	}
+	return nil
+}
+
+func reviewedProductID(p *models.Product) uint {
+	if p.SourceID != 0 {
+		return p.SourceID
+	}
+	return p.ID
+}
+
+// PostReview creates or replaces the caller's review of a store product.
+func PostReview(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var req struct {
+		Rating int    `json:"rating"`
+		Body   string `json:"body"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	userID := c.GetUint("user_id")
+
+	var product models.Product
+	if err := db.GormDB.First(&product, "id = ? AND owner_id = 0", id).Error; err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: "Product not found"})
+		return
+	}
+
+	var owned int64
+	if err := db.GormDB.Model(&models.Product{}).Where("owner_id = ? AND source_id = ?", userID, product.ID).Count(&owned).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to check ownership"})
+		return
+	}
+	if owned == 0 {
+		c.JSON(http.StatusForbidden, models.APIResponse{Success: false, Message: "Only owners of this product can review it"})
+		return
+	}
+
+	review := models.Review{
+		ProductID: product.ID,
+		UserID:    userID,
+		Rating:    req.Rating,
+		Body:      bluemonday.UGCPolicy().Sanitize(req.Body),
+	}
+	if err := models.ValidateReview(&review); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return
+	}
+
+	// Editing a review keeps any moderation decision on it
+	if err := db.GormDB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "product_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rating", "body", "updated_at"}),
+	}).Create(&review).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to save review"})
+		return
+	}
+	if err := db.GormDB.First(&review, "product_id = ? AND user_id = ?", product.ID, userID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to save review"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Review saved", Data: review})
+}
+
+func DeleteMyReview(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	result := db.GormDB.Where("product_id = ? AND user_id = ?", id, c.GetUint("user_id")).Delete(&models.Review{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to delete review"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: "Review not found"})
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Review deleted"})
+}
+
+// GetProductReviews lists the visible reviews of a store product, newest first.
+func GetProductReviews(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var product models.Product
+	if err := db.GormDB.First(&product, "id = ? AND owner_id = 0", id).Error; err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: "Product not found"})
+		return
+	}
+
+	var reviews []models.Review
+	if err := db.GormDB.Where("product_id = ? AND hidden = false", product.ID).Order("created_at DESC").Find(&reviews).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch reviews"})
+		return
+	}
+	summaries, err := ratingSummaries(db.GormDB, []uint{product.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch reviews"})
+		return
+	}
+
+	summary := summaries[product.ID]
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: gin.H{
+		"reviews":       reviews,
+		"averageRating": summary.Average,
+		"reviewCount":   summary.Count,
+	}})
+}
+
+// GetReviews is the moderation queue: all reviews, optionally filtered by
+// ?product_id and ?hidden.
+func GetReviews(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	query := db.GormDB.Order("created_at DESC")
+	if raw := c.Query("product_id"); raw != "" {
+		productID, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Invalid product_id"})
+			return
+		}
+		query = query.Where("product_id = ?", productID)
+	}
+	if raw := c.Query("hidden"); raw != "" {
+		hidden, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Invalid hidden filter"})
+			return
+		}
+		query = query.Where("hidden = ?", hidden)
+	}
+
+	var reviews []models.Review
+	if err := query.Find(&reviews).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch reviews"})
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: reviews})
+}
+
+func HideReview(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason" validate:"omitempty,max=500"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	if err := validator.New().Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return
+	}
+
+	now := time.Now()
+	setReviewHidden(c, map[string]interface{}{
+		"hidden":        true,
+		"hidden_by":     c.GetUint("user_id"),
+		"hidden_reason": bluemonday.UGCPolicy().Sanitize(req.Reason),
+		"hidden_at":     &now,
+	}, "review_hidden", "Review hidden")
+}
+
+func UnhideReview(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	setReviewHidden(c, map[string]interface{}{
+		"hidden":        false,
+		"hidden_by":     0,
+		"hidden_reason": "",
+		"hidden_at":     nil,
+	}, "review_unhidden", "Review visible again")
+}
+
+func setReviewHidden(c *gin.Context, changes map[string]interface{}, action, message string) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var review models.Review
+	if err := db.GormDB.First(&review, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: "Review not found"})
+		return
+	}
+	if err := db.GormDB.Model(&review).Updates(changes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to update review"})
+		return
+	}
+	if err := db.GormDB.First(&review, review.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to update review"})
+		return
+	}
+
+	logger.AuditLog(action, c.GetUint("user_id"), c.ClientIP(), nil)
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: message, Data: review})
+}
@@ -8,6 +8,14 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+const mostFavoritedLimit = 5
+
+type favoriteCount struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	Favorites int64  `json:"favorites"`
+}
+
 func GetStats(c *gin.Context) {
 	if db.GormDB == nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
@@ -48,6 +56,26 @@ func GetStats(c *gin.Context) {
 		return
 	}
 
+	var favoritePets, favoriteProducts []favoriteCount
+	if err := tx.Table("favorites").
+		Select("pets.id, pets.name, COUNT(*) AS favorites").
+		Joins("JOIN pets ON pets.id = favorites.item_id").
+		Where("favorites.item_type = ?", models.ItemTypePet).
+		Group("pets.id, pets.name").Order("favorites DESC, pets.id").Limit(mostFavoritedLimit).
+		Scan(&favoritePets).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch favorite pets"})
+		return
+	}
+	if err := tx.Table("favorites").
+		Select("products.id, products.name, COUNT(*) AS favorites").
+		Joins("JOIN products ON products.id = favorites.item_id").
+		Where("favorites.item_type = ?", models.ItemTypeProduct).
+		Group("products.id, products.name").Order("favorites DESC, products.id").Limit(mostFavoritedLimit).
+		Scan(&favoriteProducts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch favorite products"})
+		return
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Transaction failed"})
 		return
@@ -63,6 +91,10 @@ func GetStats(c *gin.Context) {
 			"totalProducts": productCount,
 			"ownedProducts": ownedProducts,
 			"storeProducts": storeProducts,
+			"mostFavorited": gin.H{
+				"pets":     favoritePets,
+				"products": favoriteProducts,
+			},
 		},
 	}
 	c.JSON(http.StatusOK, stats)
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"cursed_backend/internal/db"
+	"cursed_backend/internal/jobs"
+	"cursed_backend/internal/logger"
+	"cursed_backend/internal/metrics"
+	"cursed_backend/internal/models"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// lowStockScope selects store products at or below their reorder threshold.
+// A threshold of 0 means the product is not tracked.
+func lowStockScope(query *gorm.DB) *gorm.DB {
+	return query.Where("owner_id = 0 AND reorder_threshold > 0 AND stock <= reorder_threshold")
+}
+
+// crossesReorderThreshold reports whether taking quantity off the product's
+// current stock moves it from above its threshold to at or below it.
+func crossesReorderThreshold(product *models.Product, quantity int) bool {
+	return product.ReorderThreshold > 0 &&
+		product.Stock > product.ReorderThreshold &&
+		product.Stock-quantity <= product.ReorderThreshold
+}
+
+// emitLowStockAlert records that a purchase took product down to its
+// reorder threshold. product.Stock is the stock left after the purchase. The
+// low-stock gauge is left to RefreshLowStockGauge, which counts each product once.
+func emitLowStockAlert(product *models.Product) {
+	metrics.LowStockAlerts.Inc()
+	logger.Log.WithFields(map[string]interface{}{
+		"event":      "low_stock",
+		"product_id": product.ID,
+		"name":       product.Name,
+		"stock":      product.Stock,
+		"threshold":  product.ReorderThreshold,
+	}).Warn("Product reached reorder threshold")
+}
+
+func GetLowStockProducts(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var products []models.Product
+	if err := db.GormDB.Scopes(lowStockScope).Order("stock - reorder_threshold, id").Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch low-stock products"})
+		return
+	}
+	metrics.LowStockProducts.Set(float64(len(products)))
+
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: products})
+}
+
+// RefreshLowStockGauge recounts the low-stock products for the Prometheus gauge.
+func RefreshLowStockGauge() error {
+	if db.GormDB == nil {
+		return errors.New("database not available")
+	}
+	var count int64
+	if err := db.GormDB.Model(&models.Product{}).Scopes(lowStockScope).Count(&count).Error; err != nil {
+		return err
+	}
+	metrics.LowStockProducts.Set(float64(count))
+	return nil
+}
+
+// StartLowStockMonitor keeps the low-stock gauge current every interval until
+// ctx is done. Restocks and threshold edits show up on the next refresh.
+func StartLowStockMonitor(ctx context.Context, interval time.Duration) {
+	if err := RefreshLowStockGauge(); err != nil {
+		logger.Log.WithError(err).Error("Failed to refresh low-stock gauge")
+	}
+	jobs.RunEvery(ctx, "low_stock_monitor", interval, RefreshLowStockGauge)
+}
@@ -0,0 +1,357 @@
+package handlers
+
+import (
+	"context"
+	"cursed_backend/internal/db"
+	"cursed_backend/internal/jobs"
+	"cursed_backend/internal/logger"
+	"cursed_backend/internal/models"
+	"cursed_backend/internal/notify"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	notificationSubscriptionFailed = "subscription_failed"
+	subscriptionBatchSize          = 100
+)
+
+var (
+	errSubscriptionMissing = &purchaseError{http.StatusNotFound, "Subscription not found"}
+	errSubscriptionState   = &purchaseError{http.StatusConflict, "Subscription cannot be changed in its current state"}
+)
+
+func CreateSubscription(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var req struct {
+		ProductID    uint       `json:"productId" validate:"required"`
+		VariantID    uint       `json:"variantId"`
+		Quantity     int        `json:"quantity" validate:"omitempty,min=1,max=100"`
+		IntervalDays int        `json:"intervalDays" validate:"required,min=1,max=365"`
+		StartAt      *time.Time `json:"startAt"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	if err := validator.New().Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
+	// The first delivery is placed on the next scheduler run unless a start is given
+	now := time.Now()
+	nextRun := now
+	if req.StartAt != nil {
+		if req.StartAt.Before(now) {
+			c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "startAt must not be in the past"})
+			return
+		}
+		nextRun = *req.StartAt
+	}
+
+	var product models.Product
+	if err := db.GormDB.First(&product, "id = ? AND owner_id = 0", req.ProductID).Error; err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: "Product not found"})
+		return
+	}
+	if _, err := findVariant(db.GormDB, &product, req.VariantID, false); err != nil {
+		writePurchaseError(c, err, "Failed to check variant")
+		return
+	}
+
+	sub := models.Subscription{
+		UserID:       c.GetUint("user_id"),
+		ProductID:    product.ID,
+		VariantID:    req.VariantID,
+		Quantity:     req.Quantity,
+		IntervalDays: req.IntervalDays,
+		NextRunAt:    nextRun,
+		Status:       models.SubscriptionStatusActive,
+	}
+	if err := db.GormDB.Create(&sub).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to create subscription"})
+		return
+	}
+
+	hideStaffFields(&product)
+	sub.Product = &product
+	c.JSON(http.StatusCreated, models.APIResponse{Success: true, Message: "Subscription created", Data: sub})
+}
+
+func MySubscriptions(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	query := db.GormDB.Where("user_id = ?", c.GetUint("user_id")).Order("created_at DESC")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var subs []models.Subscription
+	if err := query.Find(&subs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch subscriptions"})
+		return
+	}
+
+	productIDs := make([]uint, 0, len(subs))
+	for _, s := range subs {
+		productIDs = append(productIDs, s.ProductID)
+	}
+	if len(productIDs) > 0 {
+		var products []models.Product
+		if err := db.GormDB.Scopes(preloadVariants).Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch subscriptions"})
+			return
+		}
+		byID := make(map[uint]*models.Product, len(products))
+		for i := range products {
+			hideStaffFields(&products[i])
+			byID[products[i].ID] = &products[i]
+		}
+		for i := range subs {
+			subs[i].Product = byID[subs[i].ProductID]
+		}
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: subs})
+}
+
+func UpdateSubscription(c *gin.Context) {
+	var req struct {
+		Quantity     int `json:"quantity" validate:"omitempty,min=1,max=100"`
+		IntervalDays int `json:"intervalDays" validate:"omitempty,min=1,max=365"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	if err := validator.New().Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return
+	}
+
+	changeSubscription(c, "Subscription updated", func(sub *models.Subscription, _ time.Time) error {
+		if sub.Status == models.SubscriptionStatusCancelled {
+			return errSubscriptionState
+		}
+		if req.Quantity > 0 {
+			sub.Quantity = req.Quantity
+		}
+		if req.IntervalDays > 0 {
+			sub.IntervalDays = req.IntervalDays
+		}
+		return nil
+	})
+}
+
+// SkipSubscription moves the next delivery on by one interval.
+func SkipSubscription(c *gin.Context) {
+	changeSubscription(c, "Next delivery skipped", func(sub *models.Subscription, now time.Time) error {
+		if sub.Status != models.SubscriptionStatusActive && sub.Status != models.SubscriptionStatusPaused {
+			return errSubscriptionState
+		}
+		sub.NextRunAt = sub.Advance(now)
+		return nil
+	})
+}
+
+func PauseSubscription(c *gin.Context) {
+	changeSubscription(c, "Subscription paused", func(sub *models.Subscription, _ time.Time) error {
+		if sub.Status != models.SubscriptionStatusActive {
+			return errSubscriptionState
+		}
+		sub.Status = models.SubscriptionStatusPaused
+		return nil
+	})
+}
+
+// ResumeSubscription reactivates a paused or failed subscription. A delivery
+// that fell due in the meantime is placed on the next scheduler run.
+func ResumeSubscription(c *gin.Context) {
+	changeSubscription(c, "Subscription resumed", func(sub *models.Subscription, now time.Time) error {
+		if sub.Status != models.SubscriptionStatusPaused && sub.Status != models.SubscriptionStatusFailed {
+			return errSubscriptionState
+		}
+		sub.Status = models.SubscriptionStatusActive
+		sub.FailureReason = ""
+		sub.FailedAt = nil
+		if sub.NextRunAt.Before(now) {
+			sub.NextRunAt = now
+		}
+		return nil
+	})
+}
+
+func CancelSubscription(c *gin.Context) {
+	changeSubscription(c, "Subscription cancelled", func(sub *models.Subscription, _ time.Time) error {
+		if sub.Status == models.SubscriptionStatusCancelled {
+			return errSubscriptionState
+		}
+		sub.Status = models.SubscriptionStatusCancelled
+		return nil
+	})
+}
+
+// changeSubscription applies change to the caller's subscription under a row
+// lock, so it cannot interleave with a scheduled run.
+func changeSubscription(c *gin.Context, message string, change func(sub *models.Subscription, now time.Time) error) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var sub models.Subscription
+	err := db.GormDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&sub, "id = ? AND user_id = ?", id, c.GetUint("user_id")).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errSubscriptionMissing
+			}
+			return err
+		}
+		if err := change(&sub, time.Now()); err != nil {
+			return err
+		}
+		return tx.Save(&sub).Error
+	})
+	if err != nil {
+		writePurchaseError(c, err, "Failed to update subscription")
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: message, Data: sub})
+}
+
+// RunDueSubscriptions places the orders of active subscriptions whose next
+// run has come. Each subscription is handled in its own transaction.
+func RunDueSubscriptions() error {
+	if db.GormDB == nil {
+		return nil
+	}
+
+	now := time.Now()
+	var ids []uint
+	if err := db.GormDB.Model(&models.Subscription{}).
+		Where("status = ? AND next_run_at <= ?", models.SubscriptionStatusActive, now).
+		Order("next_run_at, id").Limit(subscriptionBatchSize).
+		Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+
+	var failed int
+	for _, id := range ids {
+		if err := runSubscription(id, now); err != nil {
+			failed++
+			logger.Log.WithError(err).WithField("subscription_id", id).Error("Subscription run failed")
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d subscription runs failed", failed)
+	}
+	return nil
+}
+
+// runSubscription places one delivery through the same path as BuyProduct.
+// The purchase runs in a savepoint: when it is refused for a business reason
+// (no stock, purchase limit, insufficient funds) it is rolled back and the
+// subscription is marked failed instead. Database errors abort the whole run
+// so it is retried on the next tick.
+func runSubscription(id uint, now time.Time) error {
+	var sub models.Subscription
+	var hooks afterCommit
+	var placed bool
+	var refused *purchaseError
+	err := db.GormDB.Transaction(func(tx *gorm.DB) error {
+		// SKIP LOCKED lets a customer's concurrent edit win; the run is retried next tick
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			First(&sub, "id = ? AND status = ? AND next_run_at <= ?", id, models.SubscriptionStatusActive, now).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		var order *models.Order
+		err := tx.Transaction(func(inner *gorm.DB) error {
+			book, err := newPriceBook(inner, "")
+			if err != nil {
+				return err
+			}
+			_, item, err := purchaseProduct(inner, book, &hooks, sub.UserID, sub.ProductID, sub.VariantID, sub.Quantity)
+			if err != nil {
+				return err
+			}
+			if order, err = createOrder(inner, sub.UserID, []models.OrderItem{item}); err != nil {
+				return err
+			}
+			if err := chargeOrder(inner, order); err != nil {
+				return err
+			}
+			return nil
+		})
+		switch {
+		case err == nil:
+			sub.LastRunAt = &now
+			sub.LastOrderID = order.ID
+			sub.NextRunAt = sub.Advance(now)
+			placed = true
+		case errors.As(err, &refused):
+			sub.Status = models.SubscriptionStatusFailed
+			sub.FailureReason = refused.message
+			sub.FailedAt = &now
+		default:
+			return err
+		}
+		return tx.Save(&sub).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if placed {
+		hooks.run()
+		logger.Log.WithFields(map[string]interface{}{"subscription_id": sub.ID, "order_id": sub.LastOrderID}).Info("Subscription order placed")
+	}
+	if refused != nil {
+		notifySubscriptionFailed(&sub)
+	}
+	return nil
+}
+
+func notifySubscriptionFailed(sub *models.Subscription) {
+	var user models.User
+	if err := db.GormDB.First(&user, sub.UserID).Error; err != nil {
+		logger.Log.WithError(err).WithField("subscription_id", sub.ID).Warn("Failed to load subscriber")
+		return
+	}
+	if err := notifier.Notify(notify.Message{
+		UserID:  user.ID,
+		Email:   user.Email,
+		Kind:    notificationSubscriptionFailed,
+		Subject: "Your subscription delivery could not be placed",
+		Body:    "We could not place your scheduled order: " + sub.FailureReason + ". Resume the subscription once the problem is resolved.",
+	}); err != nil {
+		logger.Log.WithError(err).WithField("subscription_id", sub.ID).Warn("Failed to notify subscriber")
+	}
+}
+
+// StartSubscriptionScheduler places due subscription orders every interval until ctx is done.
+func StartSubscriptionScheduler(ctx context.Context, interval time.Duration) {
+	jobs.RunEvery(ctx, "subscription_scheduler", interval, RunDueSubscriptions)
+}
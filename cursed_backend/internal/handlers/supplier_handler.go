@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"cursed_backend/internal/db"
+	"cursed_backend/internal/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+func GetSuppliers(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var suppliers []models.Supplier
+	if err := db.GormDB.Order("name").Find(&suppliers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch suppliers"})
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: suppliers})
+}
+
+func CreateSupplier(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var supplier models.Supplier
+	if err := c.ShouldBindJSON(&supplier); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	supplier.ID = 0
+	validate := validator.New()
+	if err := validate.Struct(&supplier); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return
+	}
+
+	if err := db.GormDB.Create(&supplier).Error; err != nil {
+		c.JSON(http.StatusConflict, models.APIResponse{Success: false, Message: "Supplier already exists"})
+		return
+	}
+	c.JSON(http.StatusCreated, models.APIResponse{Success: true, Data: supplier})
+}
+
+func UpdateSupplier(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var supplier models.Supplier
+	if err := db.GormDB.First(&supplier, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: "Supplier not found"})
+		return
+	}
+	if err := c.ShouldBindJSON(&supplier); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	supplier.ID = uint(id)
+	validate := validator.New()
+	if err := validate.Struct(&supplier); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return
+	}
+
+	if err := db.GormDB.Omit("created_at").Save(&supplier).Error; err != nil {
+		c.JSON(http.StatusConflict, models.APIResponse{Success: false, Message: "Update failed"})
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: supplier})
+}
+
+func DeleteSupplier(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var orders int64
+	if err := db.GormDB.Model(&models.PurchaseOrder{}).Where("supplier_id = ?", id).Count(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Delete failed"})
+		return
+	}
+	if orders > 0 {
+		c.JSON(http.StatusConflict, models.APIResponse{Success: false, Message: "Supplier has purchase orders"})
+		return
+	}
+
+	result := db.GormDB.Delete(&models.Supplier{}, id)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Delete failed"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: "Supplier not found"})
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Supplier deleted"})
+}
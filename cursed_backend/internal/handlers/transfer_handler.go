@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"cursed_backend/internal/db"
+	"cursed_backend/internal/logger"
+	"cursed_backend/internal/models"
+	"cursed_backend/internal/notify"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/microcosm-cc/bluemonday"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const notificationPetTransfer = "pet_transfer"
+
+var (
+	errTransferMissing    = &purchaseError{http.StatusNotFound, "Transfer not found"}
+	errTransferNotPending = &purchaseError{http.StatusConflict, "Transfer already decided"}
+	errTransferStale      = &purchaseError{http.StatusConflict, "The pet is no longer owned by the sender"}
+)
+
+// StartPetTransfer offers one of the caller's pets to the user with the given
+// email. An unknown email gets a 404: the offer would show up in MyTransfers
+// anyway, so hiding it cannot keep accounts secret. The global rate limit
+// bounds how fast emails can be tried.
+func StartPetTransfer(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var req struct {
+		Email   string `json:"email" validate:"required,email"`
+		Message string `json:"message" validate:"omitempty,max=500"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	if err := validator.New().Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	userID := c.GetUint("user_id")
+
+	var pet models.Pet
+	if err := db.GormDB.First(&pet, "id = ? AND owner_id = ?", id, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: "Pet not found"})
+		return
+	}
+
+	var recipient models.User
+	if err := db.GormDB.First(&recipient, "LOWER(email) = ?", strings.ToLower(strings.TrimSpace(req.Email))).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: "Recipient not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to start transfer"})
+		return
+	}
+	if recipient.ID == userID {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "You already own this pet"})
+		return
+	}
+	if recipient.Blocked {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Recipient cannot receive pets"})
+		return
+	}
+
+	transfer := models.PetTransfer{
+		PetID:      pet.ID,
+		FromUserID: userID,
+		ToUserID:   recipient.ID,
+		Message:    bluemonday.UGCPolicy().Sanitize(req.Message),
+		Status:     models.TransferStatusPending,
+	}
+	result := db.GormDB.Clauses(clause.OnConflict{DoNothing: true}).Create(&transfer)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to start transfer"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, models.APIResponse{Success: false, Message: "This pet already has a pending transfer"})
+		return
+	}
+
+	if err := notifier.Notify(notify.Message{
+		UserID:  recipient.ID,
+		Email:   recipient.Email,
+		Kind:    notificationPetTransfer,
+		Subject: "You have been offered " + pet.Name,
+		Body:    "Another customer wants to transfer their pet to you. Accept or decline the transfer in your account.",
+	}); err != nil {
+		logger.Log.WithError(err).WithField("transfer_id", transfer.ID).Warn("Failed to notify transfer recipient")
+	}
+
+	logger.AuditLog("pet_transfer_started", userID, c.ClientIP(), nil)
+	transfer.Pet = &pet
+	c.JSON(http.StatusCreated, models.APIResponse{Success: true, Message: "Transfer offered", Data: transfer})
+}
+
+// MyTransfers lists transfers the caller sent or received; ?direction narrows
+// it to incoming or outgoing and ?status filters by status.
+func MyTransfers(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	query := db.GormDB.Order("created_at DESC")
+	switch c.Query("direction") {
+	case "":
+		query = query.Where("from_user_id = ? OR to_user_id = ?", userID, userID)
+	case "incoming":
+		query = query.Where("to_user_id = ?", userID)
+	case "outgoing":
+		query = query.Where("from_user_id = ?", userID)
+	default:
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "direction must be incoming or outgoing"})
+		return
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var transfers []models.PetTransfer
+	if err := query.Find(&transfers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch transfers"})
+		return
+	}
+
+	petIDs := make([]uint, 0, len(transfers))
+	for _, t := range transfers {
+		petIDs = append(petIDs, t.PetID)
+	}
+	if len(petIDs) > 0 {
+		var pets []models.Pet
+		if err := db.GormDB.Where("id IN ?", petIDs).Find(&pets).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch transfers"})
+			return
+		}
+		byID := make(map[uint]*models.Pet, len(pets))
+		for i := range pets {
+			byID[pets[i].ID] = &pets[i]
+		}
+		for i := range transfers {
+			transfers[i].Pet = byID[transfers[i].PetID]
+		}
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: transfers})
+}
+
+// AcceptPetTransfer moves the pet to the recipient. The transfer and pet rows
+// are locked so a concurrent cancel, or the sender having lost the pet in the
+// meantime, cannot leave the two out of step.
+func AcceptPetTransfer(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	userID := c.GetUint("user_id")
+
+	var transfer models.PetTransfer
+	var pet models.Pet
+	err := db.GormDB.Transaction(func(tx *gorm.DB) error {
+		if err := lockTransfer(tx, &transfer, id, "to_user_id = ?", userID); err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&pet, transfer.PetID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errTransferStale
+			}
+			return err
+		}
+		if pet.OwnerID != transfer.FromUserID {
+			return errTransferStale
+		}
+
+		if err := tx.Model(&pet).Update("owner_id", userID).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		transfer.Status = models.TransferStatusAccepted
+		transfer.DecidedAt = &now
+		return tx.Save(&transfer).Error
+	})
+	if err != nil {
+		writePurchaseError(c, err, "Transfer failed")
+		return
+	}
+
+	logger.AuditLog("pet_transferred", userID, c.ClientIP(), nil)
+	logger.Log.WithFields(map[string]interface{}{
+		"transfer_id": transfer.ID,
+		"pet_id":      pet.ID,
+		"from_user":   transfer.FromUserID,
+		"to_user":     transfer.ToUserID,
+	}).Info("Pet ownership transferred")
+	transfer.Pet = &pet
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Transfer accepted", Data: transfer})
+}
+
+func DeclinePetTransfer(c *gin.Context) {
+	closePetTransfer(c, "to_user_id = ?", models.TransferStatusDeclined, "pet_transfer_declined", "Transfer declined")
+}
+
+func CancelPetTransfer(c *gin.Context) {
+	closePetTransfer(c, "from_user_id = ?", models.TransferStatusCancelled, "pet_transfer_cancelled", "Transfer cancelled")
+}
+
+// closePetTransfer ends a pending transfer without moving the pet. party
+// restricts which side of the transfer may do it.
+func closePetTransfer(c *gin.Context, party, status, action, message string) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	userID := c.GetUint("user_id")
+
+	var transfer models.PetTransfer
+	err := db.GormDB.Transaction(func(tx *gorm.DB) error {
+		if err := lockTransfer(tx, &transfer, id, party, userID); err != nil {
+			return err
+		}
+		now := time.Now()
+		transfer.Status = status
+		transfer.DecidedAt = &now
+		return tx.Save(&transfer).Error
+	})
+	if err != nil {
+		writePurchaseError(c, err, "Failed to update transfer")
+		return
+	}
+
+	logger.AuditLog(action, userID, c.ClientIP(), nil)
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: message, Data: transfer})
+}
+
+func lockTransfer(tx *gorm.DB, transfer *models.PetTransfer, id uint64, party string, userID uint) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(party, userID).First(transfer, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errTransferMissing
+		}
+		return err
+	}
+	if transfer.Status != models.TransferStatusPending {
+		return errTransferNotPending
+	}
+	return nil
+}
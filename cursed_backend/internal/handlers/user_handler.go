@@ -240,7 +240,7 @@ func BlockUser(c *gin.Context) {
 	}
 
 	user.Blocked = true
-	if err := db.GormDB.Save(&user).Error; err != nil {
+	if err := db.GormDB.Model(&user).Update("blocked", user.Blocked).Error; err != nil {
 		logger.Log.WithError(err).Error("Block user failed")
 		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Block failed"})
 		return
@@ -269,7 +269,7 @@ func UnblockUser(c *gin.Context) {
 	}
 
 	user.Blocked = false
-	if err := db.GormDB.Save(&user).Error; err != nil {
+	if err := db.GormDB.Model(&user).Update("blocked", user.Blocked).Error; err != nil {
 		logger.Log.WithError(err).Error("Unblock user failed")
 		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Unblock failed"})
 		return
@@ -311,7 +311,7 @@ func ChangeRole(c *gin.Context) {
 	}
 
 	user.Role = newRole
-	if err := db.GormDB.Save(&user).Error; err != nil {
+	if err := db.GormDB.Model(&user).Update("role", user.Role).Error; err != nil {
 		logger.Log.WithError(err).Error("Role change failed")
 		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Role change failed"})
 		return
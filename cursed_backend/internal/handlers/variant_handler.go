@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"cursed_backend/internal/db"
+	"cursed_backend/internal/models"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errVariantParentMissing = &purchaseError{http.StatusNotFound, "Store product not found"}
+	errVariantMissing       = &purchaseError{http.StatusNotFound, "Variant not found"}
+	errVariantSKUTaken      = &purchaseError{http.StatusConflict, "SKU already in use"}
+	errVariantStockManaged  = &purchaseError{http.StatusBadRequest, "Stock of a product with variants is managed per variant"}
+)
+
+// preloadVariants attaches variants to products in a stable order.
+func preloadVariants(query *gorm.DB) *gorm.DB {
+	return query.Preload("Variants", func(db *gorm.DB) *gorm.DB { return db.Order("id") })
+}
+
+// syncVariantStock sets a locked store product's stock to the sum of its
+// variants and records the difference in the inventory ledger.
+func syncVariantStock(tx *gorm.DB, product *models.Product, variantID, actorID uint) error {
+	var total int64
+	if err := tx.Model(&models.ProductVariant{}).Where("product_id = ?", product.ID).
+		Select("COALESCE(SUM(stock), 0)").Scan(&total).Error; err != nil {
+		return err
+	}
+	delta := int(total) - product.Stock
+	if delta == 0 {
+		return nil
+	}
+	if err := tx.Model(product).Update("stock", total).Error; err != nil {
+		return err
+	}
+	product.Stock = int(total)
+	return recordStockMovement(tx, product.ID, variantID, delta, models.StockReasonAdjustment, actorID, "", 0)
+}
+
+func lockStoreProduct(tx *gorm.DB, product *models.Product, id uint64) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(product, "id = ? AND owner_id = 0", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errVariantParentMissing
+		}
+		return err
+	}
+	return nil
+}
+
+func CreateProductVariant(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var variant models.ProductVariant
+	if err := c.ShouldBindJSON(&variant); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	if err := models.ValidateProductVariant(&variant); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var product models.Product
+	err := db.GormDB.Transaction(func(tx *gorm.DB) error {
+		if err := lockStoreProduct(tx, &product, id); err != nil {
+			return err
+		}
+		variant.ID = 0
+		variant.ProductID = product.ID
+		if err := tx.Create(&variant).Error; err != nil {
+			if isUniqueViolation(err) {
+				return errVariantSKUTaken
+			}
+			return err
+		}
+		return syncVariantStock(tx, &product, variant.ID, c.GetUint("user_id"))
+	})
+	if err != nil {
+		writePurchaseError(c, err, "Creation failed")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{Success: true, Data: variant})
+}
+
+func UpdateProductVariant(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	variantID, _ := strconv.ParseUint(c.Param("variantId"), 10, 32)
+
+	var product models.Product
+	var variant models.ProductVariant
+	var previousStock int
+	var previousPrice models.Money
+	err := db.GormDB.Transaction(func(tx *gorm.DB) error {
+		if err := lockStoreProduct(tx, &product, id); err != nil {
+			return err
+		}
+		previousStock = product.Stock
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&variant, "id = ? AND product_id = ?", variantID, product.ID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errVariantMissing
+			}
+			return err
+		}
+		previousPrice = variant.Price
+
+		// Bind over the stored row so omitted fields keep their values
+		if err := c.ShouldBindJSON(&variant); err != nil {
+			return &purchaseError{http.StatusBadRequest, err.Error()}
+		}
+		variant.ID = uint(variantID)
+		variant.ProductID = product.ID
+		if err := models.ValidateProductVariant(&variant); err != nil {
+			return &purchaseError{http.StatusBadRequest, "Validation failed: " + err.Error()}
+		}
+		if err := tx.Omit("created_at").Save(&variant).Error; err != nil {
+			if isUniqueViolation(err) {
+				return errVariantSKUTaken
+			}
+			return err
+		}
+		if err := recordPriceChange(tx, models.ItemTypeProduct, product.ID, variant.ID, previousPrice, variant.Price, c.GetUint("user_id")); err != nil {
+			return err
+		}
+		return syncVariantStock(tx, &product, variant.ID, c.GetUint("user_id"))
+	})
+	if err != nil {
+		writePurchaseError(c, err, "Update failed")
+		return
+	}
+
+	afterRestock(&product, previousStock)
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: variant})
+}
+
+func DeleteProductVariant(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	variantID, _ := strconv.ParseUint(c.Param("variantId"), 10, 32)
+
+	err := db.GormDB.Transaction(func(tx *gorm.DB) error {
+		var product models.Product
+		if err := lockStoreProduct(tx, &product, id); err != nil {
+			return err
+		}
+		result := tx.Where("id = ? AND product_id = ?", variantID, product.ID).Delete(&models.ProductVariant{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errVariantMissing
+		}
+		return syncVariantStock(tx, &product, uint(variantID), c.GetUint("user_id"))
+	})
+	if err != nil {
+		writePurchaseError(c, err, "Delete failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Variant deleted"})
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"cursed_backend/internal/db"
+	"cursed_backend/internal/logger"
+	"cursed_backend/internal/models"
+	"cursed_backend/internal/notify"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const notificationBackInStock = "back_in_stock"
+
+func JoinWaitlist(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	userID := c.GetUint("user_id")
+
+	var product models.Product
+	if err := db.GormDB.First(&product, "id = ? AND owner_id = 0", id).Error; err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: "Product not found"})
+		return
+	}
+	if product.Stock > 0 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Product is in stock"})
+		return
+	}
+
+	entry := models.WaitlistEntry{UserID: userID, ProductID: product.ID}
+	result := db.GormDB.Clauses(clause.OnConflict{DoNothing: true}).Create(&entry)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to join waitlist"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		// Already waiting; answer with the existing entry
+		if err := db.GormDB.First(&entry, "user_id = ? AND product_id = ? AND notified_at IS NULL", userID, product.ID).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to join waitlist"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "You will be notified when the product is back in stock", Data: entry})
+}
+
+func LeaveWaitlist(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	result := db.GormDB.Where("user_id = ? AND product_id = ? AND notified_at IS NULL", c.GetUint("user_id"), id).Delete(&models.WaitlistEntry{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to leave waitlist"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: "Not on the waitlist for this product"})
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Removed from waitlist"})
+}
+
+func MyWaitlist(c *gin.Context) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var entries []models.WaitlistEntry
+	if err := db.GormDB.Where("user_id = ? AND notified_at IS NULL", c.GetUint("user_id")).Order("created_at").Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch waitlist"})
+		return
+	}
+	for i := range entries {
+		var product models.Product
+		if err := db.GormDB.First(&product, entries[i].ProductID).Error; err == nil {
+			hideStaffFields(&product)
+			entries[i].Product = &product
+		}
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: entries})
+}
+
+// NotifyWaitlist tells everyone waiting on productID that it is back in
+// stock, oldest entry first. Each entry is claimed under a row lock and marked
+// notified in its own transaction, so concurrent runs never notify a user
+// twice. An entry that cannot be delivered is marked failed and skipped for
+// the rest of the run; it stays on the waitlist for the next restock.
+func NotifyWaitlist(productID uint) error {
+	if db.GormDB == nil {
+		return nil
+	}
+	var product models.Product
+	if err := db.GormDB.First(&product, productID).Error; err != nil {
+		return err
+	}
+
+	// Postgres keeps microseconds, so truncate to compare with failed_at
+	start := time.Now().Truncate(time.Microsecond)
+	notified, failed := 0, 0
+	for {
+		done, delivered := false, false
+		err := db.GormDB.Transaction(func(tx *gorm.DB) error {
+			var entry models.WaitlistEntry
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Where("product_id = ? AND notified_at IS NULL AND (failed_at IS NULL OR failed_at < ?)", productID, start).
+				Order("created_at, id").Limit(1).Find(&entry).Error
+			if err != nil {
+				return err
+			}
+			if entry.ID == 0 {
+				done = true
+				return nil
+			}
+
+			var user models.User
+			err = tx.First(&user, entry.UserID).Error
+			if err == nil {
+				err = notifier.Notify(notify.Message{
+					UserID:  user.ID,
+					Email:   user.Email,
+					Kind:    notificationBackInStock,
+					Subject: product.Name + " is back in stock",
+					Body:    "The product you were waiting for is available again.",
+				})
+			}
+			if err != nil {
+				logger.Log.WithFields(map[string]interface{}{"product_id": productID, "entry_id": entry.ID, "user_id": entry.UserID}).
+					WithError(err).Warn("Waitlist entry not notified")
+				return tx.Model(&entry).Update("failed_at", time.Now()).Error
+			}
+			delivered = true
+			return tx.Model(&entry).Updates(map[string]interface{}{"notified_at": time.Now(), "failed_at": nil}).Error
+		})
+		if err != nil {
+			return err
+		}
+		if done {
+			break
+		}
+		if delivered {
+			notified++
+		} else {
+			failed++
+		}
+	}
+
+	if notified > 0 || failed > 0 {
+		logger.Log.WithFields(map[string]interface{}{"product_id": productID, "notified": notified, "failed": failed}).Info("Waitlist notified")
+	}
+	return nil
+}
+
+// afterRestock queues waitlist notifications once a store product goes from
+// no stock to some. Delivery runs in the background so the caller is not held up.
+func afterRestock(product *models.Product, previousStock int) {
+	if product.OwnerID != 0 || previousStock > 0 || product.Stock <= 0 {
+		return
+	}
+	go func(id uint) {
+		if err := NotifyWaitlist(id); err != nil {
+			logger.Log.WithField("product_id", id).WithError(err).Error("Waitlist notification failed")
+		}
+	}(product.ID)
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"cursed_backend/internal/db"
+	"cursed_backend/internal/logger"
+	"cursed_backend/internal/models"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errInsufficientFunds = &purchaseError{http.StatusPaymentRequired, "Insufficient funds"}
+	errWalletNotFound    = &purchaseError{http.StatusNotFound, "User not found"}
+)
+
+// applyWalletChange locks the user's row, moves the balance by amount and
+// appends the matching ledger entry. A change that would leave the balance
+// negative is rejected with errInsufficientFunds. Must run inside a transaction.
+func applyWalletChange(tx *gorm.DB, userID uint, amount models.Money, txType string, orderID, actorID uint, note string) (*models.WalletTransaction, error) {
+	var user models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errWalletNotFound
+		}
+		return nil, err
+	}
+
+	balance := user.Balance + amount
+	if balance < 0 {
+		return nil, errInsufficientFunds
+	}
+	if err := tx.Model(&user).Update("balance", balance).Error; err != nil {
+		return nil, err
+	}
+
+	entry := models.WalletTransaction{
+		UserID:       userID,
+		Type:         txType,
+		Amount:       amount,
+		BalanceAfter: balance,
+		OrderID:      orderID,
+		ActorID:      actorID,
+		Note:         note,
+	}
+	if err := tx.Create(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// chargeOrder debits the order total from the buyer's wallet and awards the
+// loyalty points it earns.
+func chargeOrder(tx *gorm.DB, order *models.Order) error {
+	if order.Total <= 0 {
+		return nil
+	}
+	if _, err := applyWalletChange(tx, order.UserID, -order.Total, models.WalletTxPurchase, order.ID, order.UserID, ""); err != nil {
+		return err
+	}
+	return awardLoyaltyPoints(tx, order)
+}
+
+func MyWallet(c *gin.Context) {
+	writeWallet(c, c.GetUint("user_id"))
+}
+
+func GetUserWallet(c *gin.Context) {
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Invalid user ID"})
+		return
+	}
+	writeWallet(c, uint(targetID))
+}
+
+func writeWallet(c *gin.Context, userID uint) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	var user models.User
+	if err := db.GormDB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{Success: false, Message: "User not found"})
+		return
+	}
+
+	var entries []models.WalletTransaction
+	if err := db.GormDB.Where("user_id = ?", userID).Order("id DESC").Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Failed to fetch wallet transactions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: gin.H{"balance": user.Balance, "transactions": entries}})
+}
+
+func TopUpWallet(c *gin.Context) {
+	var req struct {
+		Amount models.Money `json:"amount" validate:"required,gt=0"`
+		Note   string       `json:"note" validate:"omitempty,max=255"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return
+	}
+	updateWallet(c, req.Amount, models.WalletTxTopUp, req.Note)
+}
+
+func AdjustWallet(c *gin.Context) {
+	var req struct {
+		Amount models.Money `json:"amount" validate:"required,ne=0"`
+		Note   string       `json:"note" validate:"required,max=255"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	validate := validator.New()
+	if err := validate.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Validation failed: " + err.Error()})
+		return
+	}
+	updateWallet(c, req.Amount, models.WalletTxAdjustment, req.Note)
+}
+
+// updateWallet applies an admin-initiated change to the wallet of the user in
+// the :id path parameter.
+func updateWallet(c *gin.Context, amount models.Money, txType, note string) {
+	if db.GormDB == nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Database not available"})
+		return
+	}
+
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Invalid user ID"})
+		return
+	}
+
+	var entry *models.WalletTransaction
+	err = db.GormDB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		entry, err = applyWalletChange(tx, uint(targetID), amount, txType, 0, c.GetUint("user_id"), note)
+		return err
+	})
+	if err != nil {
+		logger.AuditLog("wallet_"+txType, uint(targetID), c.ClientIP(), err)
+		writePurchaseError(c, err, "Wallet update failed")
+		return
+	}
+
+	logger.AuditLog("wallet_"+txType, uint(targetID), c.ClientIP(), nil)
+	c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "Wallet updated", Data: entry})
+}
@@ -0,0 +1,29 @@
+// Package jobs runs the periodic background work of the server process.
+package jobs
+
+import (
+	"context"
+	"cursed_backend/internal/logger"
+	"time"
+)
+
+// RunEvery calls job every interval in its own goroutine until ctx is
+// cancelled. Errors are logged and the next tick tries again.
+func RunEvery(ctx context.Context, name string, interval time.Duration, job func() error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		logger.Log.WithField("job", name).Info("Background job started")
+		for {
+			select {
+			case <-ctx.Done():
+				logger.Log.WithField("job", name).Info("Background job stopped")
+				return
+			case <-ticker.C:
+				if err := job(); err != nil {
+					logger.Log.WithField("job", name).WithError(err).Error("Background job failed")
+				}
+			}
+		}
+	}()
+}
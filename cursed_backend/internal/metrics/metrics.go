@@ -32,6 +32,12 @@ var (
 		prometheus.HistogramOpts{Name: "db_query_duration_seconds", Help: "DB query duration"},
 		[]string{"table"},
 	)
+	LowStockProducts = promauto.NewGauge(
+		prometheus.GaugeOpts{Name: "low_stock_products", Help: "Store products at or below their reorder threshold"},
+	)
+	LowStockAlerts = promauto.NewCounter(
+		prometheus.CounterOpts{Name: "low_stock_alerts_total", Help: "Purchases that took a product down to its reorder threshold"},
+	)
 	requestCount    = expvar.NewInt("requests_total")
 	goroutinesCount = expvar.NewInt("goroutines_count")
 )
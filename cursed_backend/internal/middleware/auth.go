@@ -4,6 +4,7 @@ import (
 	"cursed_backend/internal/db"
 	"cursed_backend/internal/logger"
 	"cursed_backend/internal/models"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -31,19 +32,13 @@ func JWTAuth() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
-		tokenStr := strings.Replace(authHeader, "Bearer ", "", 1)
-		token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-			return jwtSecret, nil
-		})
-		if err != nil || !token.Valid {
+		userID, role, err := parseToken(authHeader)
+		if err != nil {
 			logger.Log.WithError(err).Warn("Invalid token attempt")
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			c.Abort()
 			return
 		}
-		claims := token.Claims.(jwt.MapClaims)
-		userID := uint(claims["user_id"].(float64))
-		role := claims["role"].(string)
 
 		var user models.User
 		if err := db.GormDB.First(&user, userID).Error; err != nil || user.Blocked {
@@ -59,6 +54,59 @@ func JWTAuth() gin.HandlerFunc {
 	}
 }
 
+// OptionalJWTAuth identifies the caller when a valid token is sent but lets
+// anonymous requests through, for public routes whose output depends on who asks
+func OptionalJWTAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			c.Next()
+			return
+		}
+		userID, role, err := parseToken(authHeader)
+		if err != nil {
+			c.Next()
+			return
+		}
+		var user models.User
+		if err := db.GormDB.First(&user, userID).Error; err != nil || user.Blocked {
+			c.Next()
+			return
+		}
+
+		c.Set("user_id", userID)
+		c.Set("role", role)
+		c.Next()
+	}
+}
+
+// parseToken validates a "Bearer <jwt>" header value and returns its claims
+func parseToken(authHeader string) (uint, string, error) {
+	tokenStr := strings.Replace(authHeader, "Bearer ", "", 1)
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		return jwtSecret, nil
+	})
+	if err != nil {
+		return 0, "", err
+	}
+	if !token.Valid {
+		return 0, "", errors.New("token is not valid")
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return 0, "", errors.New("unexpected claims type")
+	}
+	userID, ok := claims["user_id"].(float64)
+	if !ok {
+		return 0, "", errors.New("user_id claim missing")
+	}
+	role, ok := claims["role"].(string)
+	if !ok {
+		return 0, "", errors.New("role claim missing")
+	}
+	return uint(userID), role, nil
+}
+
 func RoleAuth(requiredRole string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userRole := c.GetString("role")
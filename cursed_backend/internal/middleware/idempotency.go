@@ -0,0 +1,196 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"cursed_backend/internal/db"
+	"cursed_backend/internal/jobs"
+	"cursed_backend/internal/logger"
+	"cursed_backend/internal/models"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+const idempotencyHeader = "Idempotency-Key"
+
+// responseRecorder copies everything written to the client so it can be stored
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotencyStore keeps the idempotency records. claim returns the
+// unexpired record already holding the key, or nil once record is inserted.
+type idempotencyStore interface {
+	claim(record *models.IdempotencyKey, ttl time.Duration) (*models.IdempotencyKey, error)
+	release(id uint) error
+	complete(id uint, status int, contentType string, body []byte) error
+}
+
+// Idempotency middleware: POST/PUT requests carrying an Idempotency-Key header
+// run once per user+key. Retries get the stored response replayed; reusing a
+// key for a different request is rejected. Must run after JWTAuth.
+func Idempotency(ttl time.Duration) gin.HandlerFunc {
+	return idempotency(gormIdempotencyStore{}, ttl)
+}
+
+func idempotency(store idempotencyStore, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodPost && c.Request.Method != http.MethodPut {
+			c.Next()
+			return
+		}
+		key := c.GetHeader(idempotencyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > 255 {
+			c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Idempotency-Key too long"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Failed to read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		sum := sha256.Sum256(append([]byte(c.Request.Method+" "+c.Request.URL.Path+"\n"), body...))
+		record := models.IdempotencyKey{
+			UserID:      c.GetUint("user_id"),
+			Key:         key,
+			Method:      c.Request.Method,
+			Path:        c.Request.URL.Path,
+			RequestHash: hex.EncodeToString(sum[:]),
+		}
+
+		existing, err := store.claim(&record, ttl)
+		if err != nil {
+			logger.Log.WithError(err).Error("Idempotency key lookup failed")
+			c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false, Message: "Internal server error"})
+			c.Abort()
+			return
+		}
+		if existing != nil {
+			switch {
+			case existing.RequestHash != record.RequestHash:
+				c.JSON(http.StatusUnprocessableEntity, models.APIResponse{Success: false, Message: "Idempotency-Key was already used for a different request"})
+			case existing.StatusCode == 0:
+				c.JSON(http.StatusConflict, models.APIResponse{Success: false, Message: "A request with this Idempotency-Key is still in progress"})
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(existing.StatusCode, existing.ContentType, existing.ResponseBody)
+			}
+			c.Abort()
+			return
+		}
+
+		release := func() {
+			if err := store.release(record.ID); err != nil {
+				logger.Log.WithError(err).Error("Failed to release idempotency key")
+			}
+		}
+		// A panicking handler is a server failure too; free the key and let
+		// the recovery middleware answer
+		defer func() {
+			if r := recover(); r != nil {
+				release()
+				panic(r)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			// Server failures are not final; free the key so the client can retry
+			release()
+			return
+		}
+		if err := store.complete(record.ID, status, recorder.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+			logger.Log.WithError(err).Error("Failed to store idempotent response")
+		}
+	}
+}
+
+// gormIdempotencyStore keeps idempotency records in the database.
+type gormIdempotencyStore struct{}
+
+// claim inserts record unless the user already holds an unexpired row for
+// the key, which is then returned instead. The unique index decides between
+// concurrent retries.
+func (gormIdempotencyStore) claim(record *models.IdempotencyKey, ttl time.Duration) (*models.IdempotencyKey, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		result := db.GormDB.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			return nil, nil
+		}
+
+		var existing models.IdempotencyKey
+		if err := db.GormDB.Where("user_id = ? AND key = ?", record.UserID, record.Key).First(&existing).Error; err != nil {
+			return nil, err
+		}
+		if time.Since(existing.CreatedAt) <= ttl {
+			return &existing, nil
+		}
+		// Expired: drop it and try to claim again
+		record.ID = 0
+		if err := db.GormDB.Delete(&existing).Error; err != nil {
+			return nil, err
+		}
+	}
+	return nil, errors.New("idempotency key could not be claimed")
+}
+
+func (gormIdempotencyStore) release(id uint) error {
+	return db.GormDB.Delete(&models.IdempotencyKey{}, id).Error
+}
+
+func (gormIdempotencyStore) complete(id uint, status int, contentType string, body []byte) error {
+	return db.GormDB.Model(&models.IdempotencyKey{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status_code":   status,
+		"content_type":  contentType,
+		"response_body": body,
+	}).Error
+}
+
+// PurgeExpiredIdempotencyKeys deletes stored responses older than ttl
+func PurgeExpiredIdempotencyKeys(ttl time.Duration) error {
+	if db.GormDB == nil {
+		return errors.New("database not available")
+	}
+	return db.GormDB.Where("created_at < ?", time.Now().Add(-ttl)).Delete(&models.IdempotencyKey{}).Error
+}
+
+// StartIdempotencyPurge runs PurgeExpiredIdempotencyKeys every interval until ctx is done
+func StartIdempotencyPurge(ctx context.Context, interval, ttl time.Duration) {
+	jobs.RunEvery(ctx, "idempotency_purge", interval, func() error {
+		return PurgeExpiredIdempotencyKeys(ttl)
+	})
+}
@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"cursed_backend/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The package refuses to load without a JWT secret. Package variables are
+// initialized before init functions run, so tests get a throwaway one here.
+var _ = os.Setenv("JWT_SECRET", "middleware-test-secret")
+
+// memoryIdempotencyStore behaves like the unique (user_id, key) index.
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	nextID  uint
+	records map[uint]*models.IdempotencyKey
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: map[uint]*models.IdempotencyKey{}}
+}
+
+func (s *memoryIdempotencyStore) claim(record *models.IdempotencyKey, ttl time.Duration) (*models.IdempotencyKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range s.records {
+		if r.UserID == record.UserID && r.Key == record.Key {
+			existing := *r
+			return &existing, nil
+		}
+	}
+	s.nextID++
+	record.ID = s.nextID
+	record.CreatedAt = time.Now()
+	stored := *record
+	s.records[record.ID] = &stored
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) release(id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, id)
+	return nil
+}
+
+func (s *memoryIdempotencyStore) complete(id uint, status int, contentType string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.records[id]
+	r.StatusCode, r.ContentType, r.ResponseBody = status, contentType, append([]byte(nil), body...)
+	return nil
+}
+
+func (s *memoryIdempotencyStore) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.records)
+}
+
+func newIdempotencyRouter(store idempotencyStore, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(gin.CustomRecovery(func(c *gin.Context, _ any) {
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	r.Use(func(c *gin.Context) { c.Set("user_id", uint(1)) })
+	r.Use(idempotency(store, time.Hour))
+	r.POST("/orders", handler)
+	return r
+}
+
+func send(r http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(idempotencyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	calls := 0
+	r := newIdempotencyRouter(store, func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusCreated, models.APIResponse{Success: true, Message: "Order placed"})
+	})
+
+	first := send(r, "k1", `{"qty":1}`)
+	second := send(r, "k1", `{"qty":1}`)
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Errorf("replay = %d %s, want %d %s", second.Code, second.Body, first.Code, first.Body)
+	}
+	if second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("replay is missing the Idempotent-Replayed header")
+	}
+	if first.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("first response is marked as replayed")
+	}
+
+	if w := send(r, "k1", `{"qty":2}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("reused key with another body = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+	if send(r, "", `{"qty":1}`); calls != 2 {
+		t.Errorf("request without a key did not reach the handler")
+	}
+}
+
+func TestIdempotencyConcurrentDuplicate(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	entered, proceed := make(chan struct{}), make(chan struct{})
+	r := newIdempotencyRouter(store, func(c *gin.Context) {
+		close(entered)
+		<-proceed
+		c.JSON(http.StatusOK, models.APIResponse{Success: true})
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- send(r, "k1", `{}`) }()
+	<-entered
+
+	if w := send(r, "k1", `{}`); w.Code != http.StatusConflict {
+		t.Errorf("duplicate while in progress = %d, want %d", w.Code, http.StatusConflict)
+	}
+	close(proceed)
+	if w := <-done; w.Code != http.StatusOK {
+		t.Fatalf("original request = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w := send(r, "k1", `{}`); w.Code != http.StatusOK || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("retry after completion = %d replayed=%q, want a replayed 200", w.Code, w.Header().Get("Idempotent-Replayed"))
+	}
+}
+
+func TestIdempotencyReleasesKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+	}{
+		{"server error", func(c *gin.Context) { c.JSON(http.StatusInternalServerError, models.APIResponse{Success: false}) }},
+		{"panic", func(c *gin.Context) { panic("boom") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemoryIdempotencyStore()
+			failing := true
+			r := newIdempotencyRouter(store, func(c *gin.Context) {
+				if failing {
+					tt.handler(c)
+					return
+				}
+				c.JSON(http.StatusCreated, models.APIResponse{Success: true})
+			})
+
+			if w := send(r, "k1", `{}`); w.Code != http.StatusInternalServerError {
+				t.Fatalf("failing request = %d, want %d", w.Code, http.StatusInternalServerError)
+			}
+			if n := store.len(); n != 0 {
+				t.Fatalf("%d keys held after the failure, want 0", n)
+			}
+			failing = false
+			if w := send(r, "k1", `{}`); w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "" {
+				t.Errorf("retry = %d replayed=%q, want a fresh 201", w.Code, w.Header().Get("Idempotent-Replayed"))
+			}
+		})
+	}
+}
+
+func TestIdempotencyKeepsClientErrors(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	calls := 0
+	r := newIdempotencyRouter(store, func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusBadRequest, models.APIResponse{Success: false, Message: "Not enough stock"})
+	})
+	send(r, "k1", `{}`)
+	if w := send(r, "k1", `{}`); w.Code != http.StatusBadRequest || calls != 1 {
+		t.Errorf("retry of a 4xx = %d after %d calls, want a replayed 400 after 1", w.Code, calls)
+	}
+}
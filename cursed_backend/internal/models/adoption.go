@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+const (
+	AdoptionStatusPending   = "pending"
+	AdoptionStatusApproved  = "approved"
+	AdoptionStatusRejected  = "rejected"
+	AdoptionStatusWithdrawn = "withdrawn"
+)
+
+// AdoptionApplication is a request to adopt a store pet. Ownership only moves
+// when a manager approves it; at most one application per user and pet can
+// be pending at a time.
+type AdoptionApplication struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	UserID         uint       `json:"userId" gorm:"not null;index:idx_adoption_user_pet,unique,where:status = 'pending'"`
+	PetID          uint       `json:"petId" gorm:"not null;index;index:idx_adoption_user_pet,unique,where:status = 'pending'"`
+	HousingType    string     `json:"housingType" gorm:"type:varchar(20);not null"`
+	HouseholdSize  int        `json:"householdSize" gorm:"not null;default:1"`
+	HasChildren    bool       `json:"hasChildren" gorm:"default:false"`
+	HasOtherPets   bool       `json:"hasOtherPets" gorm:"default:false"`
+	Experience     string     `json:"experience" gorm:"type:varchar(20);not null"`
+	Motivation     string     `json:"motivation" gorm:"type:text;not null"`
+	Status         string     `json:"status" gorm:"type:varchar(20);not null;default:pending;index"`
+	ReviewerID     uint       `json:"reviewerId,omitempty"`
+	DecisionReason string     `json:"decisionReason,omitempty" gorm:"type:varchar(500)"`
+	OrderID        uint       `json:"orderId,omitempty"`
+	DecidedAt      *time.Time `json:"decidedAt,omitempty"`
+	Pet            *Pet       `json:"pet,omitempty" gorm:"-"`
+	CreatedAt      time.Time  `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time  `json:"updatedAt" gorm:"autoUpdateTime"`
+}
@@ -1,7 +1,10 @@
 package models
 
+// APIResponse is the envelope of every JSON response. Order is set next to
+// Data by purchases, which return the bought item as Data.
 type APIResponse struct {
 	Success bool        `json:"success"`
 	Message string      `json:"message,omitempty"`
 	Data    interface{} `json:"data,omitempty"`
+	Order   *Order      `json:"order,omitempty"`
 }
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Bundle sells several store products together at one price. Buying it takes
+// every component out of stock; Available is computed from component stock.
+type Bundle struct {
+	ID          uint         `json:"id" gorm:"primaryKey" validate:"-"`
+	Name        string       `json:"name" gorm:"not null" validate:"required,min=1,max=100"`
+	Description string       `json:"description" validate:"omitempty,max=500"`
+	Price       Money        `json:"price" gorm:"not null;default:0" validate:"required,gt=0"`
+	Currency    string       `json:"currency" gorm:"type:char(3);not null;default:'USD'" validate:"omitempty,iso4217"`
+	Image       string       `json:"image" validate:"omitempty,url"`
+	Active      bool         `json:"active" gorm:"default:true"`
+	Items       []BundleItem `json:"items" gorm:"constraint:OnDelete:CASCADE" validate:"required,min=1,dive"`
+	Available   int          `json:"available" gorm:"-" validate:"-"`
+	CreatedAt   time.Time    `json:"createdAt" gorm:"autoCreateTime" validate:"-"`
+	UpdatedAt   time.Time    `json:"updatedAt" gorm:"autoUpdateTime" validate:"-"`
+}
+
+type BundleItem struct {
+	ID        uint     `json:"id" gorm:"primaryKey" validate:"-"`
+	BundleID  uint     `json:"bundleId" gorm:"index;not null" validate:"-"`
+	ProductID uint     `json:"productId" gorm:"index;not null" validate:"required"`
+	VariantID uint     `json:"variantId,omitempty" gorm:"not null;default:0" validate:"-"`
+	Quantity  int      `json:"quantity" gorm:"not null;default:1" validate:"required,min=1"`
+	Product   *Product `json:"product,omitempty" gorm:"-" validate:"-"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+type CartItem struct {
+	ID        uint            `json:"id" gorm:"primaryKey"`
+	UserID    uint            `json:"userId" gorm:"not null;uniqueIndex:idx_cart_user_item_variant"`
+	ItemType  string          `json:"itemType" gorm:"type:varchar(20);not null;uniqueIndex:idx_cart_user_item_variant"`
+	ItemID    uint            `json:"itemId" gorm:"not null;uniqueIndex:idx_cart_user_item_variant"`
+	VariantID uint            `json:"variantId,omitempty" gorm:"not null;default:0;uniqueIndex:idx_cart_user_item_variant"`
+	Quantity  int             `json:"quantity" gorm:"not null;default:1"`
+	Pet       *Pet            `json:"pet,omitempty" gorm:"-"`
+	Product   *Product        `json:"product,omitempty" gorm:"-"`
+	Variant   *ProductVariant `json:"variant,omitempty" gorm:"-"`
+	CreatedAt time.Time       `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt time.Time       `json:"updatedAt" gorm:"autoUpdateTime"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Favorite is a store pet or product a user saved for later. Pet and Product
+// are filled in when listing favourites, depending on ItemType.
+type Favorite struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"userId" gorm:"not null;uniqueIndex:idx_favorite_user_item"`
+	ItemType  string    `json:"itemType" gorm:"type:varchar(20);not null;uniqueIndex:idx_favorite_user_item;index:idx_favorite_item"`
+	ItemID    uint      `json:"itemId" gorm:"not null;uniqueIndex:idx_favorite_user_item;index:idx_favorite_item"`
+	Pet       *Pet      `json:"pet,omitempty" gorm:"-"`
+	Product   *Product  `json:"product,omitempty" gorm:"-"`
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// FlashSale sells a set of store products at sale prices between StartsAt
+// and EndsAt. Each item has its own allotment (TotalQuantity) and an
+// optional per-customer cap; an item whose allotment is sold out falls back
+// to the regular price.
+type FlashSale struct {
+	ID        uint            `json:"id" gorm:"primaryKey" validate:"-"`
+	Name      string          `json:"name" gorm:"not null" validate:"required,min=2,max=100"`
+	StartsAt  time.Time       `json:"startsAt" gorm:"not null;index" validate:"required"`
+	EndsAt    time.Time       `json:"endsAt" gorm:"not null;index" validate:"required,gtfield=StartsAt"`
+	Active    bool            `json:"active" gorm:"not null;default:true" validate:"-"`
+	Items     []FlashSaleItem `json:"items" gorm:"constraint:OnDelete:CASCADE" validate:"required,min=1,dive"`
+	CreatedAt time.Time       `json:"createdAt" gorm:"autoCreateTime" validate:"-"`
+	UpdatedAt time.Time       `json:"updatedAt" gorm:"autoUpdateTime" validate:"-"`
+}
+
+// FlashSaleItem is one product, or one variant of it, in a flash sale.
+// PerUserLimit 0 means no cap per customer.
+type FlashSaleItem struct {
+	ID            uint  `json:"id" gorm:"primaryKey" validate:"-"`
+	FlashSaleID   uint  `json:"flashSaleId" gorm:"index;not null" validate:"-"`
+	ProductID     uint  `json:"productId" gorm:"index;not null" validate:"required"`
+	VariantID     uint  `json:"variantId,omitempty" gorm:"not null;default:0" validate:"-"`
+	SalePrice     Money `json:"salePrice" gorm:"not null" validate:"required,gt=0"`
+	TotalQuantity int   `json:"totalQuantity" gorm:"not null" validate:"required,min=1"`
+	SoldQuantity  int   `json:"soldQuantity" gorm:"not null;default:0" validate:"-"`
+	PerUserLimit  int   `json:"perUserLimit" gorm:"not null;default:0" validate:"gte=0"`
+}
+
+// FlashSaleOffer is what product listings show about a running sale.
+type FlashSaleOffer struct {
+	FlashSaleID  uint      `json:"flashSaleId"`
+	ItemID       uint      `json:"itemId"`
+	Name         string    `json:"name"`
+	VariantID    uint      `json:"variantId,omitempty"`
+	SalePrice    Money     `json:"salePrice"`
+	Remaining    int       `json:"remaining"`
+	PerUserLimit int       `json:"perUserLimit,omitempty"`
+	EndsAt       time.Time `json:"endsAt"`
+	SecondsLeft  int64     `json:"secondsLeft"`
+}
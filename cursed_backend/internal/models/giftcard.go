@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	GiftCardTxIssue  = "issue"
+	GiftCardTxRedeem = "redeem"
+	GiftCardTxRefund = "refund"
+)
+
+// GiftCard is a prepaid balance spent at purchase time. Only a SHA-256 hash
+// of the code is stored; the code itself is shown once, when the card is
+// issued. Last4 lets staff and customers tell cards apart.
+type GiftCard struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	CodeHash       string     `json:"-" gorm:"type:char(64);uniqueIndex;not null"`
+	Last4          string     `json:"last4" gorm:"type:char(4);not null"`
+	InitialBalance Money      `json:"initialBalance" gorm:"not null;default:0"`
+	Balance        Money      `json:"balance" gorm:"not null;default:0"`
+	Currency       string     `json:"currency" gorm:"type:char(3);not null;default:'USD'"`
+	Active         bool       `json:"active" gorm:"not null;default:true"`
+	ExpiresAt      *time.Time `json:"expiresAt,omitempty"`
+	IssuedBy       uint       `json:"issuedBy"`
+	Note           string     `json:"note,omitempty" gorm:"type:varchar(255)"`
+	CreatedAt      time.Time  `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time  `json:"updatedAt" gorm:"autoUpdateTime"`
+}
+
+// Usable reports whether the card can pay for something at now.
+func (g *GiftCard) Usable(now time.Time) bool {
+	return g.Active && g.Balance > 0 && (g.ExpiresAt == nil || g.ExpiresAt.After(now))
+}
+
+// Refundable reports whether money can still be put back on the card at now.
+// Unlike Usable it accepts an empty card.
+func (g *GiftCard) Refundable(now time.Time) bool {
+	return g.Active && (g.ExpiresAt == nil || g.ExpiresAt.After(now))
+}
+
+// GiftCardTransaction is one entry of a card's append-only balance ledger.
+// Amount is signed: issuing and refunds are positive, redemptions negative.
+type GiftCardTransaction struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	GiftCardID   uint      `json:"giftCardId" gorm:"index;not null"`
+	Type         string    `json:"type" gorm:"type:varchar(20);not null"`
+	Amount       Money     `json:"amount" gorm:"not null"`
+	BalanceAfter Money     `json:"balanceAfter" gorm:"not null"`
+	OrderID      uint      `json:"orderId,omitempty" gorm:"index"`
+	UserID       uint      `json:"userId,omitempty"`
+	ActorID      uint      `json:"actorId"`
+	CreatedAt    time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+func (t *GiftCardTransaction) BeforeUpdate(*gorm.DB) error {
+	return ErrImmutableLedger
+}
+
+func (t *GiftCardTransaction) BeforeDelete(*gorm.DB) error {
+	return ErrImmutableLedger
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGiftCardUsableAndRefundable(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	tests := []struct {
+		name           string
+		card           GiftCard
+		wantUsable     bool
+		wantRefundable bool
+	}{
+		{"active with balance", GiftCard{Active: true, Balance: 500}, true, true},
+		{"active and empty", GiftCard{Active: true}, false, true},
+		{"not yet expired", GiftCard{Active: true, Balance: 500, ExpiresAt: &future}, true, true},
+		{"expired", GiftCard{Active: true, Balance: 500, ExpiresAt: &past}, false, false},
+		{"deactivated", GiftCard{Balance: 500}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.card.Usable(now); got != tt.wantUsable {
+				t.Errorf("Usable() = %v, want %v", got, tt.wantUsable)
+			}
+			if got := tt.card.Refundable(now); got != tt.wantRefundable {
+				t.Errorf("Refundable() = %v, want %v", got, tt.wantRefundable)
+			}
+		})
+	}
+}
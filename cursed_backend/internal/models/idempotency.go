@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// IdempotencyKey remembers the first response to a mutating request sent with
+// an Idempotency-Key header. StatusCode stays 0 while that request is running.
+type IdempotencyKey struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserID       uint      `json:"userId" gorm:"not null;uniqueIndex:idx_idempotency_user_key"`
+	Key          string    `json:"key" gorm:"type:varchar(255);not null;uniqueIndex:idx_idempotency_user_key"`
+	Method       string    `json:"method" gorm:"type:varchar(10);not null"`
+	Path         string    `json:"path" gorm:"not null"`
+	RequestHash  string    `json:"-" gorm:"type:char(64);not null"`
+	StatusCode   int       `json:"statusCode" gorm:"not null;default:0"`
+	ContentType  string    `json:"-" gorm:"type:varchar(100)"`
+	ResponseBody []byte    `json:"-"`
+	CreatedAt    time.Time `json:"createdAt" gorm:"autoCreateTime;index"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	LoyaltyTxEarn       = "earn"
+	LoyaltyTxRedeem     = "redeem"
+	LoyaltyTxRestore    = "restore"
+	LoyaltyTxReversal   = "reversal"
+	LoyaltyTxAdjustment = "adjustment"
+)
+
+// LoyaltyTransaction is one entry of the append-only points ledger. Points is
+// signed: earned and restored points are positive, redeemed and reversed
+// ones negative.
+type LoyaltyTransaction struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserID       uint      `json:"userId" gorm:"index;not null"`
+	Type         string    `json:"type" gorm:"type:varchar(20);not null"`
+	Points       int64     `json:"points" gorm:"not null"`
+	BalanceAfter int64     `json:"balanceAfter" gorm:"not null"`
+	OrderID      uint      `json:"orderId,omitempty" gorm:"index"`
+	ActorID      uint      `json:"actorId"`
+	Note         string    `json:"note,omitempty" gorm:"type:varchar(255)"`
+	CreatedAt    time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+func (t *LoyaltyTransaction) BeforeUpdate(*gorm.DB) error {
+	return ErrImmutableLedger
+}
+
+func (t *LoyaltyTransaction) BeforeDelete(*gorm.DB) error {
+	return ErrImmutableLedger
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in minor currency units (cents), stored as a bigint so
+// totals and discounts add up exactly. The currency lives next to it on the
+// owning model. JSON keeps the old float shape for existing clients: amounts
+// are written and read as decimal major units (19.99).
+type Money int64
+
+const minorPerMajor = 100
+
+var errMoneyPrecision = errors.New("money amounts support at most 2 decimal places")
+
+// MoneyFromFloat converts a major-unit float, rounding to the nearest cent.
+func MoneyFromFloat(v float64) Money {
+	return Money(math.Round(v * minorPerMajor))
+}
+
+// ParseMoney reads a decimal major-unit amount such as "19.99" exactly.
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if strings.ContainsAny(s, "eE") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, err
+		}
+		// Exponent notation gets the same precision rule, with float tolerance
+		if v := f * minorPerMajor; math.Abs(v-math.Round(v)) > 1e-6 {
+			return 0, errMoneyPrecision
+		}
+		return MoneyFromFloat(f), nil
+	}
+
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" {
+		whole = "0"
+	}
+	if len(frac) > 2 {
+		if strings.Trim(frac[2:], "0") != "" {
+			return 0, errMoneyPrecision
+		}
+		frac = frac[:2]
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+
+	w, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid money amount %q", s)
+	}
+	f, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid money amount %q", s)
+	}
+	v := w*minorPerMajor + f
+	if neg {
+		v = -v
+	}
+	return Money(v), nil
+}
+
+func (m Money) Float64() float64 {
+	return float64(m) / minorPerMajor
+}
+
+func (m Money) String() string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/minorPerMajor, v%minorPerMajor)
+}
+
+// Times multiplies a unit amount by a quantity.
+func (m Money) Times(quantity int) Money {
+	return m * Money(quantity)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a numeric string in major units.
+func (m *Money) UnmarshalJSON(b []byte) error {
+	b = bytes.Trim(b, `"`)
+	if string(b) == "null" || len(b) == 0 {
+		return nil
+	}
+	v, err := ParseMoney(string(b))
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
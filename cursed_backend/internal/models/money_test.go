@@ -0,0 +1,112 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestMoneyJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		want  Money
+		out   string
+		isErr bool
+	}{
+		{"whole amount", `19`, 1900, `19.00`, false},
+		{"two decimals", `19.99`, 1999, `19.99`, false},
+		{"one decimal", `0.5`, 50, `0.50`, false},
+		{"zero", `0`, 0, `0.00`, false},
+		{"negative", `-12.34`, -1234, `-12.34`, false},
+		{"negative below one", `-0.05`, -5, `-0.05`, false},
+		{"numeric string", `"7.25"`, 725, `7.25`, false},
+		{"trailing zeros", `1.2500`, 125, `1.25`, false},
+		{"exponent", `1.999e1`, 1999, `19.99`, false},
+		{"sub-cent", `0.001`, 0, ``, true},
+		{"sub-cent exponent", `1e-3`, 0, ``, true},
+		{"float noise from 0.1+0.2", `0.30000000000000004`, 0, ``, true},
+		{"not a number", `"abc"`, 0, ``, true},
+		{"negative fraction", `"1.-5"`, 0, ``, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m Money
+			err := json.Unmarshal([]byte(tt.in), &m)
+			if tt.isErr {
+				if err == nil {
+					t.Fatalf("Unmarshal(%s) = %d, want an error", tt.in, m)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal(%s): %v", tt.in, err)
+			}
+			if m != tt.want {
+				t.Fatalf("Unmarshal(%s) = %d, want %d", tt.in, m, tt.want)
+			}
+			out, err := json.Marshal(m)
+			if err != nil {
+				t.Fatalf("Marshal(%d): %v", m, err)
+			}
+			if string(out) != tt.out {
+				t.Errorf("Marshal(%d) = %s, want %s", m, out, tt.out)
+			}
+		})
+	}
+}
+
+func TestMoneySubCentError(t *testing.T) {
+	if _, err := ParseMoney("19.999"); !errors.Is(err, errMoneyPrecision) {
+		t.Errorf("ParseMoney(19.999) error = %v, want %v", err, errMoneyPrecision)
+	}
+}
+
+func TestMoneyAddsExactly(t *testing.T) {
+	// The float sum is 0.30000000000000004; in cents it is exact
+	sum := MoneyFromFloat(0.1) + MoneyFromFloat(0.2)
+	if sum != 30 || sum.String() != "0.30" {
+		t.Errorf("0.1 + 0.2 = %d (%s), want 30 (0.30)", sum, sum)
+	}
+}
+
+func TestMoneyNullLeavesValue(t *testing.T) {
+	m := Money(500)
+	if err := json.Unmarshal([]byte(`null`), &m); err != nil || m != 500 {
+		t.Errorf("Unmarshal(null) = %d, %v; want 500, nil", m, err)
+	}
+}
+
+func TestMoneyTimes(t *testing.T) {
+	tests := []struct {
+		unit Money
+		qty  int
+		want Money
+	}{
+		{1999, 3, 5997},
+		{1999, 0, 0},
+		{-250, 2, -500},
+		{1, 1000000, 1000000},
+	}
+	for _, tt := range tests {
+		if got := tt.unit.Times(tt.qty); got != tt.want {
+			t.Errorf("%d.Times(%d) = %d, want %d", tt.unit, tt.qty, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyInStruct(t *testing.T) {
+	in := struct {
+		Price Money `json:"price"`
+	}{Price: 4250}
+	b, err := json.Marshal(in)
+	if err != nil || string(b) != `{"price":42.50}` {
+		t.Fatalf("Marshal = %s, %v", b, err)
+	}
+	var out struct {
+		Price Money `json:"price"`
+	}
+	if err := json.Unmarshal(b, &out); err != nil || out.Price != in.Price {
+		t.Errorf("round trip = %d, %v; want %d", out.Price, err, in.Price)
+	}
+}
@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+const (
+	ItemTypePet     = "pet"
+	ItemTypeProduct = "product"
+	ItemTypeBundle  = "bundle"
+)
+
+const (
+	OrderStatusCompleted         = "completed"
+	OrderStatusPartiallyRefunded = "partially_refunded"
+	OrderStatusRefunded          = "refunded"
+)
+
+// Order is one checkout. Total is what was charged to the wallet: the worth
+// of redeemed loyalty points (PointsDiscount) and the amount paid by gift
+// card (GiftCardAmount) are already taken off. PointsEarned is what the
+// charged amount earned.
+type Order struct {
+	ID             uint        `json:"id" gorm:"primaryKey"`
+	UserID         uint        `json:"userId" gorm:"index;not null"`
+	Status         string      `json:"status" gorm:"type:varchar(20);not null;default:completed"`
+	Total          Money       `json:"total" gorm:"not null;default:0"`
+	PointsRedeemed int64       `json:"pointsRedeemed,omitempty" gorm:"not null;default:0"`
+	PointsDiscount Money       `json:"pointsDiscount,omitempty" gorm:"not null;default:0"`
+	PointsEarned   int64       `json:"pointsEarned,omitempty" gorm:"not null;default:0"`
+	GiftCardID     uint        `json:"giftCardId,omitempty" gorm:"not null;default:0"`
+	GiftCardAmount Money       `json:"giftCardAmount,omitempty" gorm:"not null;default:0"`
+	Currency       string      `json:"currency" gorm:"type:char(3);not null;default:'USD'"`
+	Items          []OrderItem `json:"items" gorm:"constraint:OnDelete:CASCADE"`
+	CreatedAt      time.Time   `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time   `json:"updatedAt" gorm:"autoUpdateTime"`
+}
+
+// OrderItem snapshots what was bought at the moment of purchase, so later
+// edits or deletes of the store item do not change the order history.
+// UnitPrice is what was actually charged per unit: ListPrice less Discount.
+// ItemID points at the store pet/product, OwnedItemID at the row the buyer
+// now owns (the pet itself, or the owned product copy). VariantID is set when
+// a product variant was bought. A bundle is one line carrying the bundle
+// price plus one zero-priced product line per component, tagged with BundleID.
+// FlashSaleItemID is set when the line was sold at a flash sale price.
+type OrderItem struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	OrderID         uint      `json:"orderId" gorm:"index;not null"`
+	ItemType        string    `json:"itemType" gorm:"type:varchar(20);not null"`
+	ItemID          uint      `json:"itemId" gorm:"index;not null"`
+	VariantID       uint      `json:"variantId,omitempty" gorm:"not null;default:0"`
+	BundleID        uint      `json:"bundleId,omitempty" gorm:"not null;default:0"`
+	FlashSaleItemID uint      `json:"flashSaleItemId,omitempty" gorm:"not null;default:0;index"`
+	OwnedItemID     uint      `json:"ownedItemId" gorm:"index"`
+	Name            string    `json:"name" gorm:"not null"`
+	ListPrice       Money     `json:"listPrice" gorm:"not null;default:0"`
+	Discount        Money     `json:"discount" gorm:"not null;default:0"`
+	PromotionID     uint      `json:"promotionId,omitempty" gorm:"index"`
+	UnitPrice       Money     `json:"unitPrice" gorm:"not null;default:0"`
+	Quantity        int       `json:"quantity" gorm:"not null;default:1"`
+	Subtotal        Money     `json:"subtotal" gorm:"not null;default:0"`
+	Returned        int       `json:"returned" gorm:"not null;default:0"`
+	CreatedAt       time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
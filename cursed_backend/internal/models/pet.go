@@ -7,21 +7,32 @@ import (
 )
 
 type Pet struct {
-	ID          uint      `json:"id" gorm:"primaryKey" validate:"-"`
-	Name        string    `json:"name" gorm:"not null" validate:"required,min=1,max=100"`
-	Description string    `json:"description" validate:"omitempty,max=500"`
-	Price       float64   `json:"price" gorm:"not null;default:0" validate:"required,gt=0"`
-	Breed       string    `json:"breed" gorm:"not null" validate:"required,min=2,max=50"`
-	Age         int       `json:"age" gorm:"not null;default:0" validate:"required,gte=0,lte=30"`
-	Gender      string    `json:"gender" gorm:"type:varchar(10);not null" validate:"required,oneof=male female"`
-	Sterilized  bool      `json:"sterilized" gorm:"default:false"`
-	Image       string    `json:"image" gorm:"default:'default-pet.jpg'" validate:"omitempty,url"`
-	OwnerID     uint      `json:"ownerId" gorm:"index" validate:"-"`
-	CreatedAt   time.Time `json:"createdAt" gorm:"autoCreateTime" validate:"-"`
-	UpdatedAt   time.Time `json:"updatedAt" gorm:"autoUpdateTime" validate:"-"`
+	ID            uint       `json:"id" gorm:"primaryKey" validate:"-"`
+	Name          string     `json:"name" gorm:"not null" validate:"required,min=1,max=100"`
+	Description   string     `json:"description" validate:"omitempty,max=500"`
+	Price         Money      `json:"price" gorm:"not null;default:0" validate:"required,gt=0"`
+	Currency      string     `json:"currency" gorm:"type:char(3);not null;default:'USD'" validate:"omitempty,iso4217"`
+	Species       string     `json:"species" gorm:"type:varchar(20)" validate:"omitempty,oneof=dog cat bird fish rodent reptile other"`
+	Breed         string     `json:"breed" gorm:"not null" validate:"required,min=2,max=50"`
+	BreedSize     string     `json:"breedSize" gorm:"type:varchar(10)" validate:"omitempty,oneof=small medium large giant"`
+	Age           int        `json:"age" gorm:"not null;default:0" validate:"required,gte=0,lte=30"`
+	Gender        string     `json:"gender" gorm:"type:varchar(10);not null" validate:"required,oneof=male female"`
+	Sterilized    bool       `json:"sterilized" gorm:"default:false"`
+	Image         string     `json:"image" gorm:"default:'default-pet.jpg'" validate:"omitempty,url"`
+	OwnerID       uint       `json:"ownerId" gorm:"index" validate:"-"`
+	ReservedBy    uint       `json:"reservedBy,omitempty" gorm:"index;not null;default:0" validate:"-"`
+	ReservedUntil *time.Time `json:"reservedUntil,omitempty" validate:"-"`
+	IsFavorite    *bool      `json:"isFavorite,omitempty" gorm:"-" validate:"-"`
+	CreatedAt     time.Time  `json:"createdAt" gorm:"autoCreateTime" validate:"-"`
+	UpdatedAt     time.Time  `json:"updatedAt" gorm:"autoUpdateTime" validate:"-"`
 }
 
 func ValidatePet(pet *Pet) error {
 	v := validator.New(validator.WithRequiredStructEnabled())
 	return v.Struct(pet)
 }
+
+// HeldByOther reports whether an unexpired reservation keeps userID from buying the pet.
+func (p *Pet) HeldByOther(userID uint, now time.Time) bool {
+	return p.ReservedBy != 0 && p.ReservedBy != userID && p.ReservedUntil != nil && p.ReservedUntil.After(now)
+}
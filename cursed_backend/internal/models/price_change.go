@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+const (
+	PriceChangeScheduled = "scheduled"
+	PriceChangeApplied   = "applied"
+	PriceChangeCancelled = "cancelled"
+)
+
+// PriceChange is both the price history of a pet or product and the queue of
+// changes managers have scheduled. Edits through the update endpoints are
+// recorded as already applied. A scheduled change sets either NewPrice or
+// PercentOff; a percentage is taken off the price current when the change
+// applies, and OldPrice and NewPrice are filled in then. VariantID is set when
+// the change is to one variant of a product rather than the product itself.
+type PriceChange struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	ItemType    string     `json:"itemType" gorm:"type:varchar(20);not null;index:idx_price_change_item"`
+	ItemID      uint       `json:"itemId" gorm:"not null;index:idx_price_change_item"`
+	VariantID   uint       `json:"variantId,omitempty" gorm:"not null;default:0"`
+	OldPrice    Money      `json:"oldPrice" gorm:"not null;default:0"`
+	NewPrice    Money      `json:"newPrice" gorm:"not null;default:0"`
+	PercentOff  float64    `json:"percentOff,omitempty" gorm:"not null;default:0"`
+	Status      string     `json:"status" gorm:"type:varchar(20);not null;index"`
+	EffectiveAt time.Time  `json:"effectiveAt" gorm:"not null;index"`
+	AppliedAt   *time.Time `json:"appliedAt,omitempty"`
+	ActorID     uint       `json:"actorId"`
+	Note        string     `json:"note,omitempty" gorm:"type:varchar(255)"`
+	CreatedAt   time.Time  `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time  `json:"updatedAt" gorm:"autoUpdateTime"`
+}
@@ -7,18 +7,32 @@ import (
 )
 
 type Product struct {
-	ID          uint      `json:"id" gorm:"primaryKey" validate:"-"`
-	Name        string    `json:"name" gorm:"not null" validate:"required,min=1,max=100"`
-	Description string    `json:"description" validate:"omitempty,max=500"`
-	Price       float64   `json:"price" gorm:"not null;default:0" validate:"required,gt=0"`
-	Stock       int       `json:"stock" gorm:"not null;default:0" validate:"required,gte=0"`
-	Category    string    `json:"category" gorm:"type:varchar(50);not null" validate:"required,min=2,max=50"`
-	Brand       string    `json:"brand" gorm:"type:varchar(50)" validate:"omitempty,min=2,max=50"`
-	Image       string    `json:"image" gorm:"default:'default-product.jpg'" validate:"omitempty,url"`
-	Mass        float64   `json:"mass" gorm:"default:0" validate:"gte=0"`
-	OwnerID     uint      `json:"ownerId" gorm:"index" validate:"-"`
-	CreatedAt   time.Time `json:"createdAt" gorm:"autoCreateTime" validate:"-"`
-	UpdatedAt   time.Time `json:"updatedAt" gorm:"autoUpdateTime" validate:"-"`
+	ID               uint             `json:"id" gorm:"primaryKey" validate:"-"`
+	Name             string           `json:"name" gorm:"not null" validate:"required,min=1,max=100"`
+	Description      string           `json:"description" validate:"omitempty,max=500"`
+	Price            Money            `json:"price" gorm:"not null;default:0" validate:"required,gt=0"`
+	Currency         string           `json:"currency" gorm:"type:char(3);not null;default:'USD'" validate:"omitempty,iso4217"`
+	Stock            int              `json:"stock" gorm:"not null;default:0" validate:"required,gte=0"`
+	Category         string           `json:"category" gorm:"type:varchar(50);not null" validate:"required,min=2,max=50"`
+	Brand            string           `json:"brand" gorm:"type:varchar(50)" validate:"omitempty,min=2,max=50"`
+	Image            string           `json:"image" gorm:"default:'default-product.jpg'" validate:"omitempty,url"`
+	Mass             float64          `json:"mass" gorm:"default:0" validate:"gte=0"`
+	Species          string           `json:"species" gorm:"type:varchar(20)" validate:"omitempty,oneof=dog cat bird fish rodent reptile other"`
+	BreedSize        string           `json:"breedSize" gorm:"type:varchar(10)" validate:"omitempty,oneof=small medium large giant"`
+	MinPetAge        int              `json:"minPetAge" gorm:"not null;default:0" validate:"gte=0,lte=30"`
+	MaxPetAge        int              `json:"maxPetAge" gorm:"not null;default:0" validate:"gte=0,lte=30"`
+	PurchaseLimit    int              `json:"purchaseLimit" gorm:"not null;default:0" validate:"gte=0"`
+	ReorderThreshold int              `json:"reorderThreshold" gorm:"not null;default:0" validate:"gte=0"`
+	CostPrice        Money            `json:"costPrice,omitempty" gorm:"not null;default:0" validate:"gte=0"`
+	OwnerID          uint             `json:"ownerId" gorm:"index" validate:"-"`
+	SourceID         uint             `json:"sourceId,omitempty" gorm:"index" validate:"-"`
+	Variants         []ProductVariant `json:"variants,omitempty" gorm:"constraint:OnDelete:CASCADE" validate:"-"`
+	IsFavorite       *bool            `json:"isFavorite,omitempty" gorm:"-" validate:"-"`
+	AverageRating    float64          `json:"averageRating" gorm:"-" validate:"-"`
+	ReviewCount      int64            `json:"reviewCount" gorm:"-" validate:"-"`
+	FlashSales       []FlashSaleOffer `json:"flashSales,omitempty" gorm:"-" validate:"-"`
+	CreatedAt        time.Time        `json:"createdAt" gorm:"autoCreateTime" validate:"-"`
+	UpdatedAt        time.Time        `json:"updatedAt" gorm:"autoUpdateTime" validate:"-"`
 }
 
 func ValidateProduct(product *Product) error {
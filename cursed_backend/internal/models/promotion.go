@@ -0,0 +1,57 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	PromotionTypePercent = "percent"
+	PromotionTypeFixed   = "fixed"
+)
+
+const (
+	PromotionScopeAll      = "all"
+	PromotionScopeCategory = "category"
+	PromotionScopeBrand    = "brand"
+	PromotionScopeBreed    = "breed"
+)
+
+// Promotion is a discount rule. Promotions without a Code apply automatically;
+// coded ones only when the buyer supplies the code. UsageLimit 0 means uncapped.
+type Promotion struct {
+	ID         uint       `json:"id" gorm:"primaryKey" validate:"-"`
+	Name       string     `json:"name" gorm:"not null" validate:"required,min=2,max=100"`
+	Code       string     `json:"code,omitempty" gorm:"type:varchar(50);index:idx_promotions_code,unique,where:code <> ''" validate:"omitempty,alphanum,min=3,max=50"`
+	Type       string     `json:"type" gorm:"type:varchar(10);not null" validate:"required,oneof=percent fixed"`
+	PercentOff float64    `json:"percentOff" gorm:"not null;default:0" validate:"gte=0,lte=100"`
+	AmountOff  Money      `json:"amountOff" gorm:"not null;default:0" validate:"gte=0"`
+	Scope      string     `json:"scope" gorm:"type:varchar(20);not null;default:all" validate:"required,oneof=all category brand breed"`
+	ScopeValue string     `json:"scopeValue,omitempty" gorm:"type:varchar(50)" validate:"required_unless=Scope all,max=50"`
+	StartsAt   *time.Time `json:"startsAt,omitempty" validate:"-"`
+	EndsAt     *time.Time `json:"endsAt,omitempty" validate:"-"`
+	UsageLimit int        `json:"usageLimit" gorm:"not null;default:0" validate:"gte=0"`
+	UsedCount  int        `json:"usedCount" gorm:"not null;default:0" validate:"-"`
+	Active     bool       `json:"active" gorm:"not null;default:true" validate:"-"`
+	CreatedAt  time.Time  `json:"createdAt" gorm:"autoCreateTime" validate:"-"`
+	UpdatedAt  time.Time  `json:"updatedAt" gorm:"autoUpdateTime" validate:"-"`
+}
+
+func ValidatePromotion(promo *Promotion) error {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	if err := v.Struct(promo); err != nil {
+		return err
+	}
+	if promo.Type == PromotionTypePercent && promo.PercentOff <= 0 {
+		return errors.New("percentOff must be greater than 0 for percent promotions")
+	}
+	if promo.Type == PromotionTypeFixed && promo.AmountOff <= 0 {
+		return errors.New("amountOff must be greater than 0 for fixed promotions")
+	}
+	if promo.StartsAt != nil && promo.EndsAt != nil && !promo.EndsAt.After(*promo.StartsAt) {
+		return errors.New("endsAt must be after startsAt")
+	}
+	return nil
+}
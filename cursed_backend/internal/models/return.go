@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+const (
+	ReturnStatusPending  = "pending"
+	ReturnStatusApproved = "approved"
+	ReturnStatusRejected = "rejected"
+)
+
+const (
+	ReturnConditionUnopened = "unopened"
+	ReturnConditionOpened   = "opened"
+	ReturnConditionDamaged  = "damaged"
+)
+
+type ReturnRequest struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	UserID       uint       `json:"userId" gorm:"index;not null"`
+	OrderID      uint       `json:"orderId" gorm:"index;not null"`
+	OrderItemID  uint       `json:"orderItemId" gorm:"index;not null"`
+	Quantity     int        `json:"quantity" gorm:"not null;default:1"`
+	Condition    string     `json:"condition,omitempty" gorm:"type:varchar(20)"`
+	Reason       string     `json:"reason" gorm:"type:varchar(500)"`
+	Status       string     `json:"status" gorm:"type:varchar(20);not null;default:pending;index"`
+	Restocked    bool       `json:"restocked" gorm:"default:false"`
+	RefundAmount Money      `json:"refundAmount" gorm:"not null;default:0"`
+	ReviewerID   uint       `json:"reviewerId,omitempty"`
+	DecisionNote string     `json:"decisionNote,omitempty" gorm:"type:varchar(500)"`
+	DecidedAt    *time.Time `json:"decidedAt,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time  `json:"updatedAt" gorm:"autoUpdateTime"`
+}
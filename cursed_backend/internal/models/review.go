@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Review is a customer's rating of a store product. Only users who own a copy
+// of the product may review it, once per product. Hidden reviews are kept
+// but left out of public listings and rating summaries.
+type Review struct {
+	ID           uint       `json:"id" gorm:"primaryKey" validate:"-"`
+	ProductID    uint       `json:"productId" gorm:"not null;uniqueIndex:idx_review_product_user" validate:"-"`
+	UserID       uint       `json:"userId" gorm:"not null;uniqueIndex:idx_review_product_user;index" validate:"-"`
+	Rating       int        `json:"rating" gorm:"not null" validate:"required,min=1,max=5"`
+	Body         string     `json:"body" gorm:"type:varchar(2000)" validate:"omitempty,max=2000"`
+	Hidden       bool       `json:"hidden" gorm:"not null;default:false;index" validate:"-"`
+	HiddenBy     uint       `json:"hiddenBy,omitempty" validate:"-"`
+	HiddenReason string     `json:"hiddenReason,omitempty" gorm:"type:varchar(500)" validate:"-"`
+	HiddenAt     *time.Time `json:"hiddenAt,omitempty" validate:"-"`
+	CreatedAt    time.Time  `json:"createdAt" gorm:"autoCreateTime" validate:"-"`
+	UpdatedAt    time.Time  `json:"updatedAt" gorm:"autoUpdateTime" validate:"-"`
+}
+
+func ValidateReview(review *Review) error {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	return v.Struct(review)
+}
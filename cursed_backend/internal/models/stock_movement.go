@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	StockReasonInitial       = "initial"
+	StockReasonOpening       = "opening_balance"
+	StockReasonAdjustment    = "adjustment"
+	StockReasonSale          = "sale"
+	StockReasonReturn        = "return"
+	StockReasonPurchaseOrder = "purchase_order"
+)
+
+// StockMovement is one entry of the append-only store inventory ledger. Delta
+// is signed; the movements of a store product add up to its Stock. The
+// related entity (order, return request, purchase order) is optional.
+type StockMovement struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	ProductID   uint      `json:"productId" gorm:"index;not null"`
+	VariantID   uint      `json:"variantId,omitempty" gorm:"not null;default:0"`
+	Delta       int       `json:"delta" gorm:"not null"`
+	Reason      string    `json:"reason" gorm:"type:varchar(30);not null"`
+	ActorID     uint      `json:"actorId"`
+	RelatedType string    `json:"relatedType,omitempty" gorm:"type:varchar(30)"`
+	RelatedID   uint      `json:"relatedId,omitempty"`
+	CreatedAt   time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+func (m *StockMovement) BeforeUpdate(*gorm.DB) error {
+	return ErrImmutableLedger
+}
+
+func (m *StockMovement) BeforeDelete(*gorm.DB) error {
+	return ErrImmutableLedger
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+const (
+	SubscriptionStatusActive    = "active"
+	SubscriptionStatusPaused    = "paused"
+	SubscriptionStatusFailed    = "failed"
+	SubscriptionStatusCancelled = "cancelled"
+)
+
+// Subscription repeats a product purchase every IntervalDays. The scheduler
+// places an order when NextRunAt is due; a run that cannot be fulfilled, for
+// example because the product is out of stock or the wallet is short, moves
+// the subscription to failed with the reason in FailureReason until the
+// customer resumes it.
+type Subscription struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	UserID        uint       `json:"userId" gorm:"not null;index"`
+	ProductID     uint       `json:"productId" gorm:"not null;index"`
+	VariantID     uint       `json:"variantId,omitempty" gorm:"not null;default:0"`
+	Quantity      int        `json:"quantity" gorm:"not null;default:1"`
+	IntervalDays  int        `json:"intervalDays" gorm:"not null"`
+	NextRunAt     time.Time  `json:"nextRunAt" gorm:"not null;index"`
+	Status        string     `json:"status" gorm:"type:varchar(20);not null;default:active;index"`
+	LastRunAt     *time.Time `json:"lastRunAt,omitempty"`
+	LastOrderID   uint       `json:"lastOrderId,omitempty"`
+	FailureReason string     `json:"failureReason,omitempty" gorm:"type:varchar(500)"`
+	FailedAt      *time.Time `json:"failedAt,omitempty"`
+	Product       *Product   `json:"product,omitempty" gorm:"-"`
+	CreatedAt     time.Time  `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time  `json:"updatedAt" gorm:"autoUpdateTime"`
+}
+
+// Advance returns the run after NextRunAt. Runs missed while the server was
+// down or the subscription paused are not made up: if the next slot is
+// already past, counting restarts from now.
+func (s *Subscription) Advance(now time.Time) time.Time {
+	next := s.NextRunAt.AddDate(0, 0, s.IntervalDays)
+	if !next.After(now) {
+		next = now.AddDate(0, 0, s.IntervalDays)
+	}
+	return next
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+const (
+	PurchaseOrderStatusDraft             = "draft"
+	PurchaseOrderStatusSent              = "sent"
+	PurchaseOrderStatusPartiallyReceived = "partially_received"
+	PurchaseOrderStatusReceived          = "received"
+	PurchaseOrderStatusCancelled         = "cancelled"
+)
+
+type Supplier struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"type:varchar(100);not null;uniqueIndex" validate:"required,min=2,max=100"`
+	ContactName string    `json:"contactName" gorm:"type:varchar(100)" validate:"omitempty,max=100"`
+	Email       string    `json:"email" gorm:"type:varchar(255)" validate:"omitempty,email"`
+	Phone       string    `json:"phone" gorm:"type:varchar(30)" validate:"omitempty,max=30"`
+	Notes       string    `json:"notes" gorm:"type:varchar(500)" validate:"omitempty,max=500"`
+	CreatedAt   time.Time `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updatedAt" gorm:"autoUpdateTime"`
+}
+
+// PurchaseOrder restocks store products from a supplier. Lines can only be
+// edited while it is a draft; receiving adds the delivered quantities to stock.
+type PurchaseOrder struct {
+	ID         uint                `json:"id" gorm:"primaryKey"`
+	SupplierID uint                `json:"supplierId" gorm:"index;not null"`
+	Status     string              `json:"status" gorm:"type:varchar(20);not null;default:draft;index"`
+	Reference  string              `json:"reference" gorm:"type:varchar(50)"`
+	Notes      string              `json:"notes" gorm:"type:varchar(500)"`
+	CreatedBy  uint                `json:"createdBy"`
+	Total      Money               `json:"total" gorm:"not null;default:0"`
+	SentAt     *time.Time          `json:"sentAt,omitempty"`
+	ReceivedAt *time.Time          `json:"receivedAt,omitempty"`
+	Lines      []PurchaseOrderLine `json:"lines" gorm:"constraint:OnDelete:CASCADE"`
+	CreatedAt  time.Time           `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time           `json:"updatedAt" gorm:"autoUpdateTime"`
+}
+
+type PurchaseOrderLine struct {
+	ID               uint  `json:"id" gorm:"primaryKey"`
+	PurchaseOrderID  uint  `json:"purchaseOrderId" gorm:"index;not null"`
+	ProductID        uint  `json:"productId" gorm:"index;not null"`
+	VariantID        uint  `json:"variantId,omitempty" gorm:"not null;default:0"`
+	QuantityOrdered  int   `json:"quantityOrdered" gorm:"not null"`
+	QuantityReceived int   `json:"quantityReceived" gorm:"not null;default:0"`
+	UnitCost         Money `json:"unitCost" gorm:"not null;default:0"`
+}
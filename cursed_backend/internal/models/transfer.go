@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+const (
+	TransferStatusPending   = "pending"
+	TransferStatusAccepted  = "accepted"
+	TransferStatusDeclined  = "declined"
+	TransferStatusCancelled = "cancelled"
+)
+
+// PetTransfer is an owner's offer to hand a pet to another user. Ownership
+// only moves when the recipient accepts; a pet has at most one pending
+// transfer at a time.
+type PetTransfer struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	PetID      uint       `json:"petId" gorm:"not null;index;index:idx_transfer_pending_pet,unique,where:status = 'pending'"`
+	FromUserID uint       `json:"fromUserId" gorm:"not null;index"`
+	ToUserID   uint       `json:"toUserId" gorm:"not null;index"`
+	Message    string     `json:"message,omitempty" gorm:"type:varchar(500)"`
+	Status     string     `json:"status" gorm:"type:varchar(20);not null;default:pending;index"`
+	DecidedAt  *time.Time `json:"decidedAt,omitempty"`
+	Pet        *Pet       `json:"pet,omitempty" gorm:"-"`
+	CreatedAt  time.Time  `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time  `json:"updatedAt" gorm:"autoUpdateTime"`
+}
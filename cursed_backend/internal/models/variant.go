@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ProductVariant is one purchasable version of a store product, such as a
+// 2 kg or 10 kg bag. The parent's Stock is kept equal to the sum of its
+// variants' stock.
+type ProductVariant struct {
+	ID        uint      `json:"id" gorm:"primaryKey" validate:"-"`
+	ProductID uint      `json:"productId" gorm:"index;not null" validate:"-"`
+	SKU       string    `json:"sku" gorm:"type:varchar(64);not null;uniqueIndex" validate:"required,min=1,max=64"`
+	Name      string    `json:"name" gorm:"type:varchar(100);not null" validate:"required,min=1,max=100"`
+	Price     Money     `json:"price" gorm:"not null;default:0" validate:"required,gt=0"`
+	Mass      float64   `json:"mass" gorm:"default:0" validate:"gte=0"`
+	Stock     int       `json:"stock" gorm:"not null;default:0" validate:"gte=0"`
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime" validate:"-"`
+	UpdatedAt time.Time `json:"updatedAt" gorm:"autoUpdateTime" validate:"-"`
+}
+
+func ValidateProductVariant(variant *ProductVariant) error {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	return v.Struct(variant)
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// WaitlistEntry asks for a notification when a store product is back in
+// stock. Entries are served oldest first; a user has at most one entry per
+// product still waiting. FailedAt is set when the last notification attempt
+// failed; the entry keeps waiting and is retried on the next restock.
+type WaitlistEntry struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"userId" gorm:"not null;index:idx_waitlist_user_product,unique,where:notified_at IS NULL"`
+	ProductID  uint       `json:"productId" gorm:"not null;index;index:idx_waitlist_user_product,unique,where:notified_at IS NULL"`
+	NotifiedAt *time.Time `json:"notifiedAt,omitempty"`
+	FailedAt   *time.Time `json:"failedAt,omitempty"`
+	Product    *Product   `json:"product,omitempty" gorm:"-"`
+	CreatedAt  time.Time  `json:"createdAt" gorm:"autoCreateTime"`
+}
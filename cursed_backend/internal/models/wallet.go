@@ -0,0 +1,39 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	WalletTxTopUp      = "topup"
+	WalletTxAdjustment = "adjustment"
+	WalletTxPurchase   = "purchase"
+	WalletTxRefund     = "refund"
+)
+
+// WalletTransaction is one entry of the append-only balance ledger. Amount is
+// signed: credits are positive, debits negative.
+type WalletTransaction struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserID       uint      `json:"userId" gorm:"index;not null"`
+	Type         string    `json:"type" gorm:"type:varchar(20);not null"`
+	Amount       Money     `json:"amount" gorm:"not null"`
+	BalanceAfter Money     `json:"balanceAfter" gorm:"not null"`
+	OrderID      uint      `json:"orderId,omitempty" gorm:"index"`
+	ActorID      uint      `json:"actorId"`
+	Note         string    `json:"note,omitempty" gorm:"type:varchar(255)"`
+	CreatedAt    time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+var ErrImmutableLedger = errors.New("ledger entries are immutable")
+
+func (t *WalletTransaction) BeforeUpdate(*gorm.DB) error {
+	return ErrImmutableLedger
+}
+
+func (t *WalletTransaction) BeforeDelete(*gorm.DB) error {
+	return ErrImmutableLedger
+}
@@ -0,0 +1,56 @@
+// Package notify delivers customer notifications. Handlers only see the
+// Notifier interface so the delivery channel can be swapped without touching
+// business logic.
+package notify
+
+import (
+	"cursed_backend/internal/logger"
+	"sync"
+)
+
+// Message is one notification addressed to a user.
+type Message struct {
+	UserID  uint
+	Email   string
+	Kind    string
+	Subject string
+	Body    string
+}
+
+type Notifier interface {
+	Notify(msg Message) error
+}
+
+// LogNotifier writes notifications to the application log. It is the default
+// until a real delivery channel is configured.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(msg Message) error {
+	logger.Log.WithFields(map[string]interface{}{
+		"user_id": msg.UserID,
+		"email":   msg.Email,
+		"kind":    msg.Kind,
+		"subject": msg.Subject,
+	}).Info("Notification sent")
+	return nil
+}
+
+// MemoryNotifier keeps notifications in memory in the order they were sent.
+type MemoryNotifier struct {
+	mu   sync.Mutex
+	sent []Message
+}
+
+func (n *MemoryNotifier) Notify(msg Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+// Sent returns a copy of the notifications delivered so far.
+func (n *MemoryNotifier) Sent() []Message {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]Message(nil), n.sent...)
+}
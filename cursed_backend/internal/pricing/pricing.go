@@ -0,0 +1,110 @@
+// Package pricing computes final prices from list prices and promotions. It
+// has no database or HTTP dependencies so the rules can be checked in isolation.
+package pricing
+
+import (
+	"cursed_backend/internal/models"
+	"math"
+	"strings"
+	"time"
+)
+
+// Item is the subset of a pet or product that promotions match against.
+type Item struct {
+	Type     string
+	Price    models.Money
+	Category string
+	Brand    string
+	Breed    string
+}
+
+// Quote is the per-unit price of an item after the best promotion.
+type Quote struct {
+	ListPrice   models.Money `json:"listPrice"`
+	Discount    models.Money `json:"discount"`
+	FinalPrice  models.Money `json:"finalPrice"`
+	PromotionID uint         `json:"promotionId,omitempty"`
+}
+
+// NormalizeCode is how promo codes are stored and compared.
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Active reports whether the promotion can be used at now: enabled, inside
+// its validity window and under its usage cap.
+func Active(p models.Promotion, now time.Time) bool {
+	if !p.Active {
+		return false
+	}
+	if p.StartsAt != nil && now.Before(*p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && !now.Before(*p.EndsAt) {
+		return false
+	}
+	return p.UsageLimit == 0 || p.UsedCount < p.UsageLimit
+}
+
+// Matches reports whether the promotion's scope covers the item.
+func Matches(p models.Promotion, item Item) bool {
+	switch p.Scope {
+	case models.PromotionScopeAll:
+		return true
+	case models.PromotionScopeCategory:
+		return item.Type == models.ItemTypeProduct && strings.EqualFold(p.ScopeValue, item.Category)
+	case models.PromotionScopeBrand:
+		return item.Type == models.ItemTypeProduct && strings.EqualFold(p.ScopeValue, item.Brand)
+	case models.PromotionScopeBreed:
+		return item.Type == models.ItemTypePet && strings.EqualFold(p.ScopeValue, item.Breed)
+	}
+	return false
+}
+
+// Discount is the per-unit amount the promotion takes off price, rounded to
+// cents and never more than the price itself.
+func Discount(p models.Promotion, price models.Money) models.Money {
+	var off models.Money
+	switch p.Type {
+	case models.PromotionTypePercent:
+		off = models.Money(math.Round(float64(price) * p.PercentOff / 100))
+	case models.PromotionTypeFixed:
+		off = p.AmountOff
+	}
+	if off > price {
+		off = price
+	}
+	if off < 0 {
+		off = 0
+	}
+	return off
+}
+
+// Apply prices the item with the single best promotion. Automatic promotions
+// are always candidates; coded ones only when code matches. Discounts do not
+// stack: the largest wins and ties go to the lowest promotion ID, so the
+// result does not depend on the order of promos.
+func Apply(item Item, promos []models.Promotion, code string, now time.Time) Quote {
+	code = NormalizeCode(code)
+	quote := Quote{ListPrice: item.Price, FinalPrice: item.Price}
+
+	for _, p := range promos {
+		if p.Code != "" && NormalizeCode(p.Code) != code {
+			continue
+		}
+		if !Active(p, now) || !Matches(p, item) {
+			continue
+		}
+		off := Discount(p, item.Price)
+		if off <= 0 {
+			continue
+		}
+		if off > quote.Discount || (off == quote.Discount && p.ID < quote.PromotionID) {
+			quote.Discount = off
+			quote.PromotionID = p.ID
+		}
+	}
+
+	quote.FinalPrice = item.Price - quote.Discount
+	return quote
+}
@@ -0,0 +1,82 @@
+package pricing
+
+import (
+	"cursed_backend/internal/models"
+	"testing"
+	"time"
+)
+
+func percent(id uint, off float64) models.Promotion {
+	return models.Promotion{ID: id, Type: models.PromotionTypePercent, PercentOff: off, Scope: models.PromotionScopeAll, Active: true}
+}
+
+func fixed(id uint, off models.Money) models.Promotion {
+	return models.Promotion{ID: id, Type: models.PromotionTypeFixed, AmountOff: off, Scope: models.PromotionScopeAll, Active: true}
+}
+
+func with(p models.Promotion, f func(*models.Promotion)) models.Promotion {
+	f(&p)
+	return p
+}
+
+func TestApply(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	toy := Item{Type: models.ItemTypeProduct, Price: 2000, Category: "toys", Brand: "Acme"}
+	puppy := Item{Type: models.ItemTypePet, Price: 50000, Breed: "Beagle"}
+
+	tests := []struct {
+		name     string
+		item     Item
+		promos   []models.Promotion
+		code     string
+		discount models.Money
+		promoID  uint
+	}{
+		{"no promotions", toy, nil, "", 0, 0},
+		{"largest discount wins", toy, []models.Promotion{percent(1, 10), fixed(2, 500), percent(3, 20)}, "", 500, 2},
+		{"tie goes to lowest ID", toy, []models.Promotion{fixed(5, 200), percent(4, 10)}, "", 200, 4},
+		{"discounts do not stack", toy, []models.Promotion{fixed(1, 100), fixed(2, 100)}, "", 100, 1},
+		{"coded promotion needs its code", toy, []models.Promotion{percent(1, 5), with(percent(2, 50), func(p *models.Promotion) { p.Code = "HALF" })}, "", 100, 1},
+		{"code is case-insensitive and beats smaller automatic", toy, []models.Promotion{percent(1, 5), with(percent(2, 50), func(p *models.Promotion) { p.Code = "HALF" })}, " half ", 1000, 2},
+		{"automatic beats smaller coded", toy, []models.Promotion{percent(1, 30), with(percent(2, 10), func(p *models.Promotion) { p.Code = "TEN" })}, "TEN", 600, 1},
+		{"wrong code is ignored", toy, []models.Promotion{with(fixed(1, 300), func(p *models.Promotion) { p.Code = "SAVE" })}, "OTHER", 0, 0},
+		{"inactive", toy, []models.Promotion{with(fixed(1, 300), func(p *models.Promotion) { p.Active = false })}, "", 0, 0},
+		{"not started", toy, []models.Promotion{with(fixed(1, 300), func(p *models.Promotion) { p.StartsAt = &future })}, "", 0, 0},
+		{"started", toy, []models.Promotion{with(fixed(1, 300), func(p *models.Promotion) { p.StartsAt = &past })}, "", 300, 1},
+		{"expired", toy, []models.Promotion{with(fixed(1, 300), func(p *models.Promotion) { p.EndsAt = &past })}, "", 0, 0},
+		{"ends exactly now", toy, []models.Promotion{with(fixed(1, 300), func(p *models.Promotion) { p.EndsAt = &now })}, "", 0, 0},
+		{"usage limit reached", toy, []models.Promotion{with(fixed(1, 300), func(p *models.Promotion) { p.UsageLimit, p.UsedCount = 3, 3 })}, "", 0, 0},
+		{"usage limit not reached", toy, []models.Promotion{with(fixed(1, 300), func(p *models.Promotion) { p.UsageLimit, p.UsedCount = 3, 2 })}, "", 300, 1},
+		{"category scope", toy, []models.Promotion{with(fixed(1, 300), func(p *models.Promotion) { p.Scope, p.ScopeValue = models.PromotionScopeCategory, "Toys" })}, "", 300, 1},
+		{"other brand", toy, []models.Promotion{with(fixed(1, 300), func(p *models.Promotion) { p.Scope, p.ScopeValue = models.PromotionScopeBrand, "Other" })}, "", 0, 0},
+		{"breed scope only matches pets", toy, []models.Promotion{with(fixed(1, 300), func(p *models.Promotion) { p.Scope, p.ScopeValue = models.PromotionScopeBreed, "Beagle" })}, "", 0, 0},
+		{"breed scope", puppy, []models.Promotion{with(percent(1, 10), func(p *models.Promotion) { p.Scope, p.ScopeValue = models.PromotionScopeBreed, "beagle" })}, "", 5000, 1},
+		{"fixed discount capped at price", toy, []models.Promotion{fixed(1, 5000)}, "", 2000, 1},
+		{"percent rounds half away from zero", Item{Type: models.ItemTypeProduct, Price: 999}, []models.Promotion{percent(1, 50)}, "", 500, 1},
+		{"percent rounds to the nearest cent", Item{Type: models.ItemTypeProduct, Price: 1999}, []models.Promotion{percent(1, 15)}, "", 300, 1},
+		{"rounding to zero is no discount", Item{Type: models.ItemTypeProduct, Price: 1}, []models.Promotion{percent(1, 10)}, "", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := Apply(tt.item, tt.promos, tt.code, now)
+			if q.Discount != tt.discount || q.PromotionID != tt.promoID {
+				t.Errorf("Apply() discount %d promotion %d, want %d promotion %d", q.Discount, q.PromotionID, tt.discount, tt.promoID)
+			}
+			if q.ListPrice != tt.item.Price || q.FinalPrice != tt.item.Price-tt.discount {
+				t.Errorf("Apply() list %d final %d, want list %d final %d", q.ListPrice, q.FinalPrice, tt.item.Price, tt.item.Price-tt.discount)
+			}
+		})
+	}
+}
+
+func TestApplyIgnoresPromotionOrder(t *testing.T) {
+	now := time.Now()
+	item := Item{Type: models.ItemTypeProduct, Price: 1000}
+	a, b := fixed(7, 100), percent(3, 10)
+	first := Apply(item, []models.Promotion{a, b}, "", now)
+	second := Apply(item, []models.Promotion{b, a}, "", now)
+	if first != second || first.PromotionID != 3 {
+		t.Errorf("Apply() = %+v and %+v, want promotion 3 both times", first, second)
+	}
+}
@@ -0,0 +1,128 @@
+// Package recommend ranks store products for a customer's pets. Like pricing,
+// it has no database or HTTP dependencies: callers load the pets, candidate
+// products and co-purchase counts, and the scorer is a pure function of them.
+package recommend
+
+import (
+	"sort"
+	"strings"
+)
+
+// Weights of the individual signals. Tag matches dominate; co-purchases
+// order products with equal tag scores and surface products without tags.
+const (
+	speciesWeight   = 3.0
+	breedSizeWeight = 2.0
+	ageWeight       = 1.0
+	coPurchaseStep  = 0.5
+	coPurchaseCap   = 10
+)
+
+// Pet is what the scorer knows about one of the customer's pets.
+type Pet struct {
+	Species   string
+	BreedSize string
+	Age       int
+}
+
+// Candidate is a store product with its targeting tags. Empty tags and a zero
+// MaxAge mean the product is not restricted on that attribute.
+// CoPurchases counts other customers' orders that contained this product
+// together with something the customer bought.
+type Candidate struct {
+	ProductID   uint
+	Species     string
+	BreedSize   string
+	MinAge      int
+	MaxAge      int
+	CoPurchases int
+}
+
+// Result is a scored candidate with the reasons that contributed to it.
+type Result struct {
+	ProductID uint     `json:"productId"`
+	Score     float64  `json:"score"`
+	Reasons   []string `json:"reasons"`
+}
+
+// fit scores the candidate's tags against one pet. ok is false when a tag
+// rules the product out for that pet.
+func fit(p Pet, c Candidate) (score float64, reasons []string, ok bool) {
+	if c.Species != "" {
+		if !strings.EqualFold(c.Species, p.Species) {
+			return 0, nil, false
+		}
+		score += speciesWeight
+		reasons = append(reasons, "species")
+	}
+	if c.BreedSize != "" {
+		if !strings.EqualFold(c.BreedSize, p.BreedSize) {
+			return 0, nil, false
+		}
+		score += breedSizeWeight
+		reasons = append(reasons, "breed_size")
+	}
+	if c.MinAge > 0 || c.MaxAge > 0 {
+		if p.Age < c.MinAge || (c.MaxAge > 0 && p.Age > c.MaxAge) {
+			return 0, nil, false
+		}
+		score += ageWeight
+		reasons = append(reasons, "age")
+	}
+	return score, reasons, true
+}
+
+// Score rates one candidate for the customer's pets: the best tag fit over
+// all pets plus a capped co-purchase bonus. ok is false when the product
+// suits none of the pets, when nothing speaks for it (no matching tag and no
+// co-purchases), or, for customers without pets, when it has targeting tags.
+func Score(pets []Pet, c Candidate) (Result, bool) {
+	res := Result{ProductID: c.ProductID}
+	matched := false
+	for _, p := range pets {
+		s, reasons, ok := fit(p, c)
+		if !ok {
+			continue
+		}
+		if !matched || s > res.Score {
+			res.Score, res.Reasons = s, reasons
+		}
+		matched = true
+	}
+	if len(pets) == 0 {
+		if c.Species != "" || c.BreedSize != "" || c.MinAge > 0 || c.MaxAge > 0 {
+			return Result{}, false
+		}
+		matched = true
+	}
+	if !matched || (res.Score == 0 && c.CoPurchases == 0) {
+		return Result{}, false
+	}
+
+	if c.CoPurchases > 0 {
+		res.Score += coPurchaseStep * float64(min(c.CoPurchases, coPurchaseCap))
+		res.Reasons = append(res.Reasons, "bought_together")
+	}
+	return res, true
+}
+
+// Rank scores every candidate and returns up to limit results, best first.
+// Ties are broken by product ID so the order is stable.
+func Rank(pets []Pet, candidates []Candidate, limit int) []Result {
+	results := make([]Result, 0, len(candidates))
+	for _, c := range candidates {
+		if r, ok := Score(pets, c); ok {
+			results = append(results, r)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ProductID < results[j].ProductID
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
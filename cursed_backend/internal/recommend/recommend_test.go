@@ -0,0 +1,105 @@
+package recommend
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFit(t *testing.T) {
+	dog := Pet{Species: "dog", BreedSize: "large", Age: 4}
+	tests := []struct {
+		name    string
+		c       Candidate
+		score   float64
+		reasons []string
+		ok      bool
+	}{
+		{"untagged", Candidate{}, 0, nil, true},
+		{"species match ignores case", Candidate{Species: "Dog"}, 3, []string{"species"}, true},
+		{"all tags match", Candidate{Species: "dog", BreedSize: "large", MinAge: 2, MaxAge: 8}, 6, []string{"species", "breed_size", "age"}, true},
+		{"open-ended age range", Candidate{MinAge: 1}, 1, []string{"age"}, true},
+		{"other species", Candidate{Species: "cat"}, 0, nil, false},
+		{"other breed size", Candidate{Species: "dog", BreedSize: "small"}, 0, nil, false},
+		{"too young", Candidate{MinAge: 5}, 0, nil, false},
+		{"too old", Candidate{MaxAge: 3}, 0, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, reasons, ok := fit(dog, tt.c)
+			if ok != tt.ok || score != tt.score || !reflect.DeepEqual(reasons, tt.reasons) {
+				t.Errorf("fit() = %v, %v, %v; want %v, %v, %v", score, reasons, ok, tt.score, tt.reasons, tt.ok)
+			}
+		})
+	}
+}
+
+func TestScore(t *testing.T) {
+	pets := []Pet{
+		{Species: "cat", BreedSize: "small", Age: 1},
+		{Species: "dog", BreedSize: "large", Age: 6},
+	}
+	tests := []struct {
+		name    string
+		pets    []Pet
+		c       Candidate
+		score   float64
+		reasons []string
+		ok      bool
+	}{
+		{"best pet wins", pets, Candidate{Species: "dog", BreedSize: "large"}, 5, []string{"species", "breed_size"}, true},
+		{"excluded for every pet", pets, Candidate{Species: "bird"}, 0, nil, false},
+		{"untagged without co-purchases", pets, Candidate{}, 0, nil, false},
+		{"untagged with co-purchases", pets, Candidate{CoPurchases: 2}, 1, []string{"bought_together"}, true},
+		{"co-purchase bonus", pets, Candidate{Species: "cat", CoPurchases: 4}, 5, []string{"species", "bought_together"}, true},
+		{"co-purchase bonus is capped", pets, Candidate{Species: "cat", CoPurchases: 50}, 8, []string{"species", "bought_together"}, true},
+		{"no pets, untagged with co-purchases", nil, Candidate{CoPurchases: 3}, 1.5, []string{"bought_together"}, true},
+		{"no pets, untagged without co-purchases", nil, Candidate{}, 0, nil, false},
+		{"no pets, tagged", nil, Candidate{Species: "dog", CoPurchases: 3}, 0, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.c.ProductID = 7
+			res, ok := Score(tt.pets, tt.c)
+			if ok != tt.ok {
+				t.Fatalf("Score() ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if res.ProductID != 7 || res.Score != tt.score || !reflect.DeepEqual(res.Reasons, tt.reasons) {
+				t.Errorf("Score() = %+v, want score %v reasons %v", res, tt.score, tt.reasons)
+			}
+		})
+	}
+}
+
+func TestRank(t *testing.T) {
+	pets := []Pet{{Species: "dog", BreedSize: "small", Age: 2}}
+	candidates := []Candidate{
+		{ProductID: 4, Species: "dog"},
+		{ProductID: 2, Species: "dog"},
+		{ProductID: 9, Species: "dog", BreedSize: "small"},
+		{ProductID: 1, Species: "cat"},
+		{ProductID: 3},
+		{ProductID: 5, CoPurchases: 1},
+	}
+	tests := []struct {
+		name  string
+		limit int
+		want  []uint
+	}{
+		{"ties broken by product ID", 0, []uint{9, 2, 4, 5}},
+		{"limit", 2, []uint{9, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []uint
+			for _, r := range Rank(pets, candidates, tt.limit) {
+				got = append(got, r.ProductID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Rank() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -22,6 +22,8 @@ import (
 var rateLimiters = make(map[string]*rate.Limiter)
 
 func SetupRouter(cfg *config.Config) *gin.Engine {
+	handlers.Configure(cfg)
+
 	r := gin.Default()
 	err := r.SetTrustedProxies(nil)
 	if err != nil {
@@ -40,8 +42,8 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     origins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-CSRF-Token"},
-		ExposeHeaders:    []string{"Content-Length", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-CSRF-Token", "Idempotency-Key"},
+		ExposeHeaders:    []string{"Content-Length", "Authorization", "Idempotent-Replayed"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -63,8 +65,11 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 	{
 		public.POST("/register", handlers.Register)
 		public.POST("/login", handlers.Login)
-		public.GET("/pets", handlers.GetPets)
-		public.GET("/products", handlers.GetProducts)
+		public.GET("/pets", middleware.OptionalJWTAuth(), handlers.GetPets)
+		public.GET("/products", middleware.OptionalJWTAuth(), handlers.GetProducts)
+		public.GET("/products/:id/reviews", handlers.GetProductReviews)
+		public.GET("/bundles", middleware.OptionalJWTAuth(), handlers.GetBundles)
+		public.GET("/flash-sales", middleware.OptionalJWTAuth(), handlers.GetFlashSales)
 		public.GET("/stats", handlers.GetStats)
 		public.GET("/health", handlers.HealthCheck)
 
@@ -76,14 +81,53 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 	r.Use(middleware.ErrorHandler())
 
 	// Protected routes
-	protected := r.Group("/api").Use(middleware.JWTAuth(), middleware.CSRF())
+	protected := r.Group("/api").Use(middleware.JWTAuth(), middleware.CSRF(), middleware.Idempotency(cfg.IdempotencyKeyTTL))
 	{
 		protected.POST("/refresh", handlers.RefreshToken)
 		protected.PUT("/user", handlers.UpdateUser)
 		protected.GET("/my/pets", handlers.MyPets)
+		protected.POST("/my/pets/:id/transfer", handlers.StartPetTransfer)
+		protected.GET("/my/transfers", handlers.MyTransfers)
+		protected.POST("/my/transfers/:id/accept", handlers.AcceptPetTransfer)
+		protected.POST("/my/transfers/:id/decline", handlers.DeclinePetTransfer)
+		protected.POST("/my/transfers/:id/cancel", handlers.CancelPetTransfer)
 		protected.GET("/my/products", handlers.MyProducts)
+		protected.GET("/my/orders", handlers.MyOrders)
+		protected.GET("/my/wallet", handlers.MyWallet)
+		protected.GET("/my/loyalty", handlers.MyLoyalty)
+		protected.POST("/gift-cards/check", handlers.CheckGiftCard)
+		protected.GET("/my/returns", handlers.MyReturns)
+		protected.POST("/my/returns", handlers.CreateReturn)
 		protected.POST("/pets/:id/buy", handlers.BuyPet)
+		protected.POST("/pets/:id/adopt", handlers.ApplyForAdoption)
+		protected.GET("/my/adoptions", handlers.MyAdoptions)
+		protected.GET("/my/adoptions/:id", handlers.MyAdoption)
+		protected.DELETE("/my/adoptions/:id", handlers.WithdrawAdoption)
+		protected.POST("/pets/:id/reserve", handlers.ReservePet)
+		protected.DELETE("/pets/:id/reserve", handlers.ReleasePet)
 		protected.POST("/products/:id/buy", handlers.BuyProduct)
+		protected.POST("/products/:id/waitlist", handlers.JoinWaitlist)
+		protected.DELETE("/products/:id/waitlist", handlers.LeaveWaitlist)
+		protected.GET("/my/waitlist", handlers.MyWaitlist)
+		protected.POST("/products/:id/reviews", handlers.PostReview)
+		protected.DELETE("/products/:id/reviews", handlers.DeleteMyReview)
+		protected.GET("/my/recommendations", handlers.MyRecommendations)
+		protected.GET("/my/favorites", handlers.MyFavorites)
+		protected.POST("/my/favorites", handlers.AddFavorite)
+		protected.DELETE("/my/favorites", handlers.RemoveFavorite)
+		protected.GET("/my/subscriptions", handlers.MySubscriptions)
+		protected.POST("/my/subscriptions", handlers.CreateSubscription)
+		protected.PUT("/my/subscriptions/:id", handlers.UpdateSubscription)
+		protected.DELETE("/my/subscriptions/:id", handlers.CancelSubscription)
+		protected.POST("/my/subscriptions/:id/skip", handlers.SkipSubscription)
+		protected.POST("/my/subscriptions/:id/pause", handlers.PauseSubscription)
+		protected.POST("/my/subscriptions/:id/resume", handlers.ResumeSubscription)
+		protected.POST("/bundles/:id/buy", handlers.BuyBundle)
+		protected.GET("/cart", handlers.GetCart)
+		protected.POST("/cart", handlers.AddToCart)
+		protected.PUT("/cart/:id", handlers.UpdateCartItem)
+		protected.DELETE("/cart/:id", handlers.RemoveFromCart)
+		protected.POST("/checkout", handlers.Checkout)
 	}
 
 	// Manager routes
@@ -92,11 +136,58 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 		manager.POST("/pets", handlers.CreatePet)
 		manager.GET("/pets/:id", handlers.GetPet)
 		manager.PUT("/pets/:id", handlers.UpdatePet)
+		manager.GET("/pets/:id/price-history", handlers.GetPetPriceHistory)
+		manager.POST("/pets/:id/price-changes", handlers.SchedulePetPriceChange)
 		manager.DELETE("/pets/:id", handlers.DeletePet)
 		manager.POST("/products", handlers.CreateProduct)
+		manager.GET("/products/low-stock", handlers.GetLowStockProducts)
 		manager.GET("/products/:id", handlers.GetProduct)
+		manager.GET("/products/:id/movements", handlers.GetProductMovements)
+		manager.GET("/products/:id/price-history", handlers.GetProductPriceHistory)
+		manager.POST("/products/:id/price-changes", handlers.ScheduleProductPriceChange)
+		manager.GET("/price-changes", handlers.GetPriceChanges)
+		manager.DELETE("/price-changes/:id", handlers.CancelPriceChange)
+		manager.POST("/products/:id/variants", handlers.CreateProductVariant)
+		manager.PUT("/products/:id/variants/:variantId", handlers.UpdateProductVariant)
+		manager.DELETE("/products/:id/variants/:variantId", handlers.DeleteProductVariant)
+		manager.GET("/inventory/reconciliation", handlers.GetStockReconciliation)
 		manager.PUT("/products/:id", handlers.UpdateProduct)
 		manager.DELETE("/products/:id", handlers.DeleteProduct)
+		manager.GET("/promotions", handlers.GetPromotions)
+		manager.POST("/promotions", handlers.CreatePromotion)
+		manager.PUT("/promotions/:id", handlers.UpdatePromotion)
+		manager.DELETE("/promotions/:id", handlers.DeletePromotion)
+		manager.GET("/returns", handlers.GetReturns)
+		manager.POST("/returns/:id/approve", handlers.ApproveReturn)
+		manager.POST("/returns/:id/reject", handlers.RejectReturn)
+		manager.GET("/reviews", handlers.GetReviews)
+		manager.POST("/reviews/:id/hide", handlers.HideReview)
+		manager.POST("/reviews/:id/unhide", handlers.UnhideReview)
+		manager.GET("/adoptions", handlers.GetAdoptions)
+		manager.GET("/adoptions/:id", handlers.GetAdoption)
+		manager.POST("/adoptions/:id/approve", handlers.ApproveAdoption)
+		manager.POST("/adoptions/:id/reject", handlers.RejectAdoption)
+		manager.POST("/bundles", handlers.CreateBundle)
+		manager.PUT("/bundles/:id", handlers.UpdateBundle)
+		manager.DELETE("/bundles/:id", handlers.DeleteBundle)
+		manager.POST("/flash-sales", handlers.CreateFlashSale)
+		manager.PUT("/flash-sales/:id", handlers.UpdateFlashSale)
+		manager.DELETE("/flash-sales/:id", handlers.DeleteFlashSale)
+		manager.GET("/gift-cards", handlers.GetGiftCards)
+		manager.POST("/gift-cards", handlers.IssueGiftCard)
+		manager.GET("/gift-cards/:id", handlers.GetGiftCard)
+		manager.POST("/gift-cards/:id/deactivate", handlers.DeactivateGiftCard)
+		manager.GET("/suppliers", handlers.GetSuppliers)
+		manager.POST("/suppliers", handlers.CreateSupplier)
+		manager.PUT("/suppliers/:id", handlers.UpdateSupplier)
+		manager.DELETE("/suppliers/:id", handlers.DeleteSupplier)
+		manager.GET("/purchase-orders", handlers.GetPurchaseOrders)
+		manager.POST("/purchase-orders", handlers.CreatePurchaseOrder)
+		manager.GET("/purchase-orders/:id", handlers.GetPurchaseOrder)
+		manager.PUT("/purchase-orders/:id", handlers.UpdatePurchaseOrder)
+		manager.POST("/purchase-orders/:id/send", handlers.SendPurchaseOrder)
+		manager.POST("/purchase-orders/:id/receive", handlers.ReceivePurchaseOrder)
+		manager.POST("/purchase-orders/:id/cancel", handlers.CancelPurchaseOrder)
 	}
 
 	// Admin routes
@@ -107,6 +198,12 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 		admin.POST("/users/:id/block", handlers.BlockUser)
 		admin.POST("/users/:id/unblock", handlers.UnblockUser)
 		admin.PUT("/users/:id/role", handlers.ChangeRole)
+		admin.GET("/users/:id/wallet", handlers.GetUserWallet)
+		admin.POST("/users/:id/wallet/topup", handlers.TopUpWallet)
+		admin.POST("/users/:id/wallet/adjust", handlers.AdjustWallet)
+		admin.GET("/users/:id/loyalty", handlers.GetUserLoyalty)
+		admin.POST("/users/:id/loyalty/adjust", handlers.AdjustLoyalty)
+		admin.GET("/orders", handlers.GetOrders)
 		admin.GET("/debug/vars", gin.WrapH(expvar.Handler())) // Protected
 	}
 